@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+
+	"github.com/gocraft/dbr"
+)
+
+// BATCH_SIZE is the maximum number of rows that CreateMany and DeleteMany change with a single statement
+const BATCH_SIZE = 500
+
+func (r BaseRepository) CreateMany(objects interface{}) error {
+	return r.CreateManyContext(context.Background(), objects)
+}
+
+// CreateManyContext inserts a slice of models with multi-row INSERT statements of at most BATCH_SIZE rows.  The
+// statements run inside of a transaction so either all of the models are created or none are.
+func (r BaseRepository) CreateManyContext(ctx context.Context, objects interface{}) error {
+	items, err := getSliceItems(objects)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	return r.WithTx(ctx, func(repo Repository) error {
+		tx := repo.(*BaseRepository)
+		for _, batch := range getBatches(items) {
+			if err := tx.insert(ctx, batch); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// insert creates all of the items with a single statement
+func (r BaseRepository) insert(ctx context.Context, items []interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	columns := r.Sh.GetTagValues(items[0], "db")
+	stmt := r.runner().InsertInto(r.Table).Columns(columns...)
+	// the values are passed so dbr doesn't set the LastInsertId of the whole statement on one of the models
+	for _, item := range items {
+		stmt.Record(reflect.Indirect(reflect.ValueOf(item)).Interface())
+	}
+
+	_, err := stmt.ExecContext(ctx)
+
+	return err
+}
+
+func (r BaseRepository) UpdateMany(objects interface{}) error {
+	return r.UpdateManyContext(context.Background(), objects)
+}
+
+// UpdateManyContext saves a slice of models inside of a transaction.  Every row has its own values so each model is
+// updated by its own statement.  An error for any of the models, e.g. a ConflictError, rolls back all of them and
+// restores the versions the models were loaded with.
+func (r BaseRepository) UpdateManyContext(ctx context.Context, objects interface{}) error {
+	return r.updateMany(ctx, objects, func(tx *BaseRepository, i int, item interface{}) error {
+		return tx.UpdateContext(ctx, item)
+	})
+}
+
+func (r BaseRepository) UpdateFieldsMany(objects interface{}, fields [][]string) error {
+	return r.UpdateFieldsManyContext(context.Background(), objects, fields)
+}
+
+// UpdateFieldsManyContext saves the fields of each model of a slice inside of a transaction, the same as UpdateFields.
+// The fields are given per model, in the same order as the slice, so every model only changes the columns that were
+// set on it.  It rolls back and restores the versions the same as UpdateManyContext.
+func (r BaseRepository) UpdateFieldsManyContext(ctx context.Context, objects interface{}, fields [][]string) error {
+	return r.updateMany(ctx, objects, func(tx *BaseRepository, i int, item interface{}) error {
+		if i >= len(fields) {
+			return errors.New("must pass the fields of every object to UpdateFieldsMany")
+		}
+
+		return tx.UpdateFieldsContext(ctx, item, fields[i])
+	})
+}
+
+// updateMany calls update with each model of a slice inside of a transaction and restores the versions the models were
+// loaded with when any of them fails
+func (r BaseRepository) updateMany(ctx context.Context, objects interface{}, update func(tx *BaseRepository, i int, item interface{}) error) error {
+	items, err := getSliceItems(objects)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	versions := make([]reflect.Value, len(items))
+	for i, item := range items {
+		if version, ok := getVersionField(item); ok {
+			versions[i] = reflect.New(version.value.Type()).Elem()
+			versions[i].Set(version.value)
+		}
+	}
+
+	err = r.WithTx(ctx, func(repo Repository) error {
+		for i, item := range items {
+			if err := update(repo.(*BaseRepository), i, item); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		for i, item := range items {
+			if version, ok := getVersionField(item); ok {
+				version.value.Set(versions[i])
+			}
+		}
+	}
+
+	return err
+}
+
+func (r BaseRepository) DeleteMany(objects interface{}) error {
+	return r.DeleteManyContext(context.Background(), objects)
+}
+
+// DeleteManyContext removes a slice of models inside of a transaction, the same as Delete.  Soft deletable models have
+// their deleted column set instead.  Versioned models, see VERSION_TAG, are deleted by their own statement so each row
+// is checked against its version and a ConflictError rolls back all of them and restores the versions.  The other
+// models are removed with `id IN (...)` statements of at most BATCH_SIZE ids and dbr.ErrNotFound is returned when one
+// of the rows is missing or already deleted.
+func (r BaseRepository) DeleteManyContext(ctx context.Context, objects interface{}) error {
+	items, err := getSliceItems(objects)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	if _, ok := getVersionField(items[0]); ok {
+		return r.updateMany(ctx, objects, func(tx *BaseRepository, i int, item interface{}) error {
+			return tx.DeleteContext(ctx, item)
+		})
+	}
+
+	// an id that is listed more than once is only removed once, so it doesn't count as a missing row
+	ids := make([]interface{}, 0, len(items))
+	seen := make(map[interface{}]bool, len(items))
+	for _, item := range items {
+		id := r.Sh.GetMapByTag(item, "structs")["id"]
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	column, softDelete := GetSoftDeleteColumn(items[0])
+
+	return r.WithTx(ctx, func(repo Repository) error {
+		tx := repo.(*BaseRepository)
+		for _, batch := range getBatches(ids) {
+			var err error
+			if softDelete {
+				err = tx.softDeleteMany(ctx, column, batch)
+			} else {
+				err = tx.purgeMany(ctx, batch)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r BaseRepository) softDeleteMany(ctx context.Context, column string, ids []interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.runner().
+		Update(r.Table).
+		Set(column, now().UTC()).
+		Where(dbr.Eq("id", ids)).
+		Where(dbr.Eq(column, nil)).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result, len(ids))
+}
+
+func (r BaseRepository) purgeMany(ctx context.Context, ids []interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	result, err := r.runner().DeleteFrom(r.Table).Where(dbr.Eq("id", ids)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(result, len(ids))
+}
+
+// checkRowsAffected returns dbr.ErrNotFound when a statement changed fewer rows than expected
+func checkRowsAffected(result sql.Result, expected int) error {
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows < int64(expected) {
+		return dbr.ErrNotFound
+	}
+
+	return nil
+}
+
+// getSliceItems returns a pointer to each of the models of a slice, or of a pointer to a slice, so that the changes
+// made by the repository (e.g. a new version) are visible to the caller.  Slices of pointers are supported as well.
+func getSliceItems(objects interface{}) ([]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(objects))
+	if v.Kind() != reflect.Slice {
+		return nil, errors.New("must pass a slice to repository batch methods")
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		item := v.Index(i)
+		if item.Kind() != reflect.Ptr {
+			item = item.Addr()
+		}
+
+		if item.IsNil() || item.Elem().Kind() != reflect.Struct {
+			return nil, errors.New("must pass a slice of structs to repository batch methods")
+		}
+
+		items[i] = item.Interface()
+	}
+
+	return items, nil
+}
+
+// getBatches splits the items into batches of at most BATCH_SIZE
+func getBatches(items []interface{}) [][]interface{} {
+	batches := make([][]interface{}, 0, (len(items)+BATCH_SIZE-1)/BATCH_SIZE)
+	for start := 0; start < len(items); start += BATCH_SIZE {
+		end := start + BATCH_SIZE
+		if end > len(items) {
+			end = len(items)
+		}
+
+		batches = append(batches, items[start:end])
+	}
+
+	return batches
+}
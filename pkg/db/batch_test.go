@@ -0,0 +1,285 @@
+package db
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+)
+
+func TestBaseRepository_CreateMany(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	table := "resource"
+	repo := NewRepository(sess, structs.Helper{}, table).(*BaseRepository)
+
+	t.Run("Insert all of the models with one statement", func(t *testing.T) {
+		objects := []MockObject{{"1", "one"}, {"2", "two"}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedQuery(t, sess, sess.InsertInto(table).Columns("id", "name").Record(objects[0]).Record(objects[1]))).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		if err := repo.CreateMany(objects); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Split the models into batches", func(t *testing.T) {
+		objects := make([]*MockObject, BATCH_SIZE+1)
+		for i := range objects {
+			objects[i] = &MockObject{Id: "id", Name: "name"}
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "resource"`).WillReturnResult(sqlmock.NewResult(0, BATCH_SIZE))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "resource" ("id","name") VALUES ('id','name')`) + `$`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repo.CreateMany(&objects); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Roll back when a batch fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "resource"`).WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
+
+		if err := repo.CreateMany([]MockObject{{"1", "one"}}); err == nil || err.Error() != "insert failed" {
+			t.Errorf("Expected the insert error, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Do nothing for an empty slice", func(t *testing.T) {
+		if err := repo.CreateMany([]MockObject{}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Return an error when the objects are not a slice", func(t *testing.T) {
+		if err := repo.CreateMany(MockObject{}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
+
+func TestBaseRepository_UpdateMany(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	t.Run("Update each model inside of a transaction", func(t *testing.T) {
+		objects := []MockVersionObject{{Id: "1", Version: 1}, {Id: "2", Version: 5}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`WHERE \(id = '1'\) AND \("version" = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`WHERE \(id = '2'\) AND \("version" = 5\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repo.UpdateMany(objects); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if objects[0].Version != 2 || objects[1].Version != 6 {
+			t.Errorf("Expected the new versions to be set, got %d and %d", objects[0].Version, objects[1].Version)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Roll back and restore the versions on a conflict", func(t *testing.T) {
+		objects := []MockVersionObject{{Id: "1", Version: 1}, {Id: "2", Version: 5}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`WHERE \(id = '1'\) AND \("version" = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`WHERE \(id = '2'\) AND \("version" = 5\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		var conflict *ConflictError
+		if err := repo.UpdateMany(objects); !errors.As(err, &conflict) || conflict.Id != "2" {
+			t.Fatalf("Expected a conflict for '2', got %v", err)
+		}
+
+		if objects[0].Version != 1 || objects[1].Version != 5 {
+			t.Errorf("Expected the versions to be restored, got %d and %d", objects[0].Version, objects[1].Version)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestBaseRepository_UpdateFieldsMany(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	t.Run("Only set the fields of each model", func(t *testing.T) {
+		objects := []MockVersionObject{{Id: "1", Name: "first", Version: 1}, {Id: "2", Name: "second", Version: 5}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "resource" SET "(name|version)" = ('first'|2), "(name|version)" = ('first'|2) WHERE \(id = '1'\) AND \("version" = 1\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "resource" SET "version" = 6 WHERE (id = '2') AND ("version" = 5)`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repo.UpdateFieldsMany(objects, [][]string{{"Name"}, {}}); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Roll back when the fields of a model are missing", func(t *testing.T) {
+		objects := []MockVersionObject{{Id: "1", Name: "first", Version: 1}, {Id: "2", Name: "second", Version: 5}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`WHERE \(id = '1'\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		if err := repo.UpdateFieldsMany(objects, [][]string{{"Name"}}); err == nil {
+			t.Fatal("Expected an error")
+		}
+
+		if objects[0].Version != 1 {
+			t.Errorf("Expected the version to be restored, got %d", objects[0].Version)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestBaseRepository_DeleteMany(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	table := "resource"
+	repo := NewRepository(sess, structs.Helper{}, table).(*BaseRepository)
+
+	t.Run("Delete all of the models with one statement", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedQuery(t, sess, sess.DeleteFrom(table).Where(dbr.Eq("id", []interface{}{"1", "2"})))).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		if err := repo.DeleteMany([]MockObject{{"1", "one"}, {"2", "two"}}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Set the deleted column of soft deletable models", func(t *testing.T) {
+		deletedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+		now = func() time.Time { return deletedAt }
+		defer func() { now = time.Now }()
+
+		stmt := sess.Update(table).
+			Set("deleted_at", deletedAt).
+			Where(dbr.Eq("id", []interface{}{"1", "2"})).
+			Where(dbr.Eq("deleted_at", nil))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedQuery(t, sess, stmt)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		if err := repo.DeleteMany([]MockSoftDeleteObject{{Id: "1"}, {Id: "2"}}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Roll back when a row is missing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(expectedQuery(t, sess, sess.DeleteFrom(table).Where(dbr.Eq("id", []interface{}{"1", "2"})))).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		if err := repo.DeleteMany([]MockObject{{"1", "one"}, {"2", "two"}, {"1", "one"}}); err != dbr.ErrNotFound {
+			t.Errorf("Expected %v, got %v", dbr.ErrNotFound, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Delete each versioned model by its version", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "resource" WHERE \(id = '1'\) AND \("version" = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM "resource" WHERE \(id = '2'\) AND \("version" = 5\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repo.DeleteMany([]MockVersionObject{{Id: "1", Version: 1}, {Id: "2", Version: 5}}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Roll back when the version of a model is stale", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "resource" WHERE \(id = '1'\) AND \("version" = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM "resource" WHERE \(id = '2'\) AND \("version" = 5\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		var conflict *ConflictError
+		if err := repo.DeleteMany([]MockVersionObject{{Id: "1", Version: 1}, {Id: "2", Version: 5}}); !errors.As(err, &conflict) || conflict.Id != "2" {
+			t.Errorf("Expected a conflict for '2', got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
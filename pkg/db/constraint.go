@@ -0,0 +1,34 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// mysqlConstraintErrors are the MySQL error numbers of duplicate keys, foreign keys and null columns
+var mysqlConstraintErrors = map[uint64]bool{1048: true, 1062: true, 1216: true, 1217: true, 1451: true, 1452: true}
+
+// IsConstraintError returns true when the error is a constraint violation of the database, e.g. a duplicate key or a
+// missing foreign key, rather than a failure of the database itself.  Postgres errors have a SQLSTATE of class 23 and
+// MySQL errors have one of the numbers of mysqlConstraintErrors.  The drivers are matched by their methods and members
+// so the library doesn't depend on them.
+func IsConstraintError(err error) bool {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		return strings.HasPrefix(state.SQLState(), "23")
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+
+		if number := v.FieldByName("Number"); number.IsValid() && number.Kind() == reflect.Uint16 {
+			return mysqlConstraintErrors[number.Uint()]
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type mockPostgresError struct {
+	code string
+}
+
+func (e *mockPostgresError) Error() string {
+	return "pq: " + e.code
+}
+
+func (e *mockPostgresError) SQLState() string {
+	return e.code
+}
+
+type mockMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mockMySQLError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+func TestIsConstraintError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Postgres unique violation", &mockPostgresError{"23505"}, true},
+		{"Postgres foreign key violation", fmt.Errorf("insert: %w", &mockPostgresError{"23503"}), true},
+		{"Postgres connection failure", &mockPostgresError{"08006"}, false},
+		{"MySQL duplicate entry", &mockMySQLError{Number: 1062}, true},
+		{"MySQL lock timeout", &mockMySQLError{Number: 1205}, false},
+		{"Other error", errors.New("connection refused"), false},
+		{"No error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if IsConstraintError(tt.err) != tt.expected {
+				t.Errorf("Expected %t for %v", tt.expected, tt.err)
+			}
+		})
+	}
+}
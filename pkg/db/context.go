@@ -0,0 +1,67 @@
+package db
+
+import "context"
+
+// FindContext calls repo.FindContext when the repository is a ContextRepository and repo.Find otherwise
+func FindContext(ctx context.Context, repo Repository, object interface{}, id string) error {
+	if cr, ok := repo.(ContextRepository); ok {
+		return cr.FindContext(ctx, object, id)
+	}
+
+	return repo.Find(object, id)
+}
+
+// FindByContext calls repo.FindByContext when the repository is a ContextRepository and repo.FindBy otherwise
+func FindByContext(ctx context.Context, repo Repository, objects interface{}, fb FindBy) error {
+	if cr, ok := repo.(ContextRepository); ok {
+		return cr.FindByContext(ctx, objects, fb)
+	}
+
+	return repo.FindBy(objects, fb)
+}
+
+// CountContext calls repo.CountContext when the repository is a ContextRepository and repo.Count otherwise
+func CountContext(ctx context.Context, repo Repository, object interface{}, fb FindBy) (int, error) {
+	if cr, ok := repo.(ContextRepository); ok {
+		return cr.CountContext(ctx, object, fb)
+	}
+
+	return repo.Count(object, fb)
+}
+
+// CreateContext calls repo.CreateContext when the repository is a ContextRepository and repo.Create otherwise
+func CreateContext(ctx context.Context, repo Repository, object interface{}) error {
+	if cr, ok := repo.(ContextRepository); ok {
+		return cr.CreateContext(ctx, object)
+	}
+
+	return repo.Create(object)
+}
+
+// UpdateContext calls repo.UpdateContext when the repository is a ContextRepository and repo.Update otherwise
+func UpdateContext(ctx context.Context, repo Repository, object interface{}) error {
+	if cr, ok := repo.(ContextRepository); ok {
+		return cr.UpdateContext(ctx, object)
+	}
+
+	return repo.Update(object)
+}
+
+// UpdateFieldsContext calls repo.UpdateFieldsContext when the repository is a FieldsUpdater.  Otherwise every column
+// of the model is saved with UpdateContext.
+func UpdateFieldsContext(ctx context.Context, repo Repository, object interface{}, fields []string) error {
+	if fu, ok := repo.(FieldsUpdater); ok {
+		return fu.UpdateFieldsContext(ctx, object, fields)
+	}
+
+	return UpdateContext(ctx, repo, object)
+}
+
+// DeleteContext calls repo.DeleteContext when the repository is a ContextRepository and repo.Delete otherwise
+func DeleteContext(ctx context.Context, repo Repository, object interface{}) error {
+	if cr, ok := repo.(ContextRepository); ok {
+		return cr.DeleteContext(ctx, object)
+	}
+
+	return repo.Delete(object)
+}
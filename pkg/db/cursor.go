@@ -0,0 +1,171 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Cursor is a position within a sorted collection used for keyset pagination.  Values are the values of the sorted
+// fields of the row the cursor points to, in the same order as FindBy.Sorting().  When Before is true the rows prior to
+// the cursor are returned instead of the rows after it.
+type Cursor struct {
+	Values []interface{}
+	Before bool
+}
+
+// GetCursorValues returns the values of the sorted fields of the object so they can be used to create a Cursor
+func GetCursorValues(object interface{}, sorting []Sort) ([]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(object))
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New("object not a struct")
+	}
+
+	values := make([]interface{}, len(sorting))
+	for i, s := range sorting {
+		field, ok := getFieldByJsonTag(v, s.Field)
+		if !ok {
+			return nil, fmt.Errorf("property '%s' does not exist", s.Field)
+		}
+
+		value := field.Interface()
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			if value, err = valuer.Value(); err != nil {
+				return nil, err
+			}
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// ValidateCursorSorting returns an error when one of the sorted fields of the object can be null.  The rows after a
+// cursor are found by comparing the sorted columns with the values of the cursor and a comparison with NULL is never
+// true, so the rows with a null value would be skipped.
+func ValidateCursorSorting(object interface{}, sorting []Sort) error {
+	v := reflect.Indirect(reflect.ValueOf(object))
+	if v.Kind() != reflect.Struct {
+		return errors.New("object not a struct")
+	}
+
+	for _, s := range sorting {
+		field, ok := getFieldByJsonTag(v, s.Field)
+		if !ok {
+			return fmt.Errorf("property '%s' does not exist", s.Field)
+		}
+
+		if isNullable(field.Type()) {
+			return fmt.Errorf("property '%s' can be null so it can't be sorted with a cursor", s.Field)
+		}
+	}
+
+	return nil
+}
+
+// getCursorColumnValues returns the values of the cursor for the sorted columns of the object.  Times are strings in a
+// cursor token so they are parsed again to be compared with the column as a time.
+func getCursorColumnValues(object interface{}, sorting []Sort, cursor Cursor) ([]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(object))
+	values := make([]interface{}, len(cursor.Values))
+	for i, value := range cursor.Values {
+		values[i] = value
+
+		field, _ := getFieldByJsonTag(v, sorting[i].Field)
+		s, ok := value.(string)
+		if !ok || !isTime(field.Type()) {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, errors.New("cursor is not valid")
+		}
+
+		values[i] = t
+	}
+
+	return values, nil
+}
+
+// isNullable returns true for pointers and the null types, e.g. types.NullString, which have a Valid member
+func isNullable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return true
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	valid, ok := t.FieldByName("Valid")
+
+	return ok && valid.Type.Kind() == reflect.Bool
+}
+
+// isTime returns true for a time.Time and the types that embed one, e.g. types.Datetime
+func isTime(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	embedded, ok := t.FieldByName("Time")
+
+	return ok && embedded.Anonymous && embedded.Type == timeType
+}
+
+// keysetCondition returns the condition that selects the rows after the cursor.  For a sort of a, b, c the condition
+// is (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND c > ?) where the comparison follows the sort direction.
+func keysetCondition(columns []string, sorting []Sort, cursor Cursor) dbr.Builder {
+	conditions := make([]dbr.Builder, len(sorting))
+	for i, s := range sorting {
+		and := make([]dbr.Builder, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, dbr.Eq(columns[j], cursor.Values[j]))
+		}
+
+		if (s.Direction == SORT_ASC) != cursor.Before {
+			and = append(and, dbr.Gt(columns[i], cursor.Values[i]))
+		} else {
+			and = append(and, dbr.Lt(columns[i], cursor.Values[i]))
+		}
+
+		conditions[i] = dbr.And(and...)
+	}
+
+	return dbr.Or(conditions...)
+}
+
+// reverseSlice reverses the slice that the pointer points to in place
+func reverseSlice(objects interface{}) {
+	v := reflect.ValueOf(objects).Elem()
+	swap := reflect.Swapper(v.Interface())
+	for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+// getFieldByJsonTag returns the struct member with the json field name
+func getFieldByJsonTag(v reflect.Value, jsonField string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == jsonField {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gocraft/dbr"
+)
+
+const (
+	OPERATOR_EQ      = "eq"
+	OPERATOR_NEQ     = "neq"
+	OPERATOR_GT      = "gt"
+	OPERATOR_GTE     = "gte"
+	OPERATOR_LT      = "lt"
+	OPERATOR_LTE     = "lte"
+	OPERATOR_IN      = "in"
+	OPERATOR_BETWEEN = "between"
+	OPERATOR_NULL    = "null"
+)
+
+// Operators is the list of all of the supported filter operators
+var Operators = []string{
+	OPERATOR_EQ,
+	OPERATOR_NEQ,
+	OPERATOR_GT,
+	OPERATOR_GTE,
+	OPERATOR_LT,
+	OPERATOR_LTE,
+	OPERATOR_IN,
+	OPERATOR_BETWEEN,
+	OPERATOR_NULL,
+}
+
+// Filter is a single condition applied to a field.  The field is the json name of the struct member.  The value
+// depends on the operator:
+//  * in: a slice of values
+//  * between: a slice with exactly two values, the lower and upper bound
+//  * null: a bool, true for IS NULL and false for IS NOT NULL
+//  * all others: a single value
+type Filter struct {
+	Field    string
+	Operator string
+	Value    interface{}
+}
+
+// IsOperator returns true if the operator is supported
+func IsOperator(operator string) bool {
+	for _, o := range Operators {
+		if o == operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// condition translates the filter into a dbr condition for the column
+func (f Filter) condition(column string) (dbr.Builder, error) {
+	switch f.Operator {
+	case OPERATOR_EQ:
+		return dbr.Eq(column, f.Value), nil
+	case OPERATOR_NEQ:
+		return dbr.Neq(column, f.Value), nil
+	case OPERATOR_GT:
+		return dbr.Gt(column, f.Value), nil
+	case OPERATOR_GTE:
+		return dbr.Gte(column, f.Value), nil
+	case OPERATOR_LT:
+		return dbr.Lt(column, f.Value), nil
+	case OPERATOR_LTE:
+		return dbr.Lte(column, f.Value), nil
+	case OPERATOR_IN:
+		if reflect.ValueOf(f.Value).Kind() != reflect.Slice {
+			return nil, fmt.Errorf("property '%s' must be filtered by a list of values for '%s'", f.Field, f.Operator)
+		}
+
+		return dbr.Eq(column, f.Value), nil
+	case OPERATOR_BETWEEN:
+		v := reflect.ValueOf(f.Value)
+		if v.Kind() != reflect.Slice || v.Len() != 2 {
+			return nil, fmt.Errorf("property '%s' must be filtered by two values for '%s'", f.Field, f.Operator)
+		}
+
+		return dbr.And(dbr.Gte(column, v.Index(0).Interface()), dbr.Lte(column, v.Index(1).Interface())), nil
+	case OPERATOR_NULL:
+		isNull, ok := f.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("property '%s' must be filtered by a boolean for '%s'", f.Field, f.Operator)
+		}
+
+		if isNull {
+			return dbr.Eq(column, nil), nil
+		}
+
+		return dbr.Neq(column, nil), nil
+	}
+
+	return nil, fmt.Errorf("operator '%s' is not supported", f.Operator)
+}
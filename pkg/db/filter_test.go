@@ -0,0 +1,85 @@
+package db
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+)
+
+func TestIsOperator(t *testing.T) {
+	for _, o := range Operators {
+		if !IsOperator(o) {
+			t.Errorf("Expected %s to be an operator", o)
+		}
+	}
+
+	if IsOperator("like") {
+		t.Error("Did not expect like to be an operator")
+	}
+}
+
+func TestBaseRepository_FindByFilters(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	table := "resource"
+	repo := NewRepository(sess, structs.Helper{}, table)
+
+	fb := FindBy{
+		Filters: []Filter{
+			{Field: "id", Operator: OPERATOR_IN, Value: []interface{}{"1", "2"}},
+			{Field: "name", Operator: OPERATOR_BETWEEN, Value: []interface{}{"a", "m"}},
+			{Field: "name", Operator: OPERATOR_NULL, Value: false},
+			{Field: "name", Operator: OPERATOR_NEQ, Value: "z"},
+		},
+	}
+
+	buff := dbr.NewBuffer()
+	sess.Select("*").From(table).
+		Where(dbr.Eq("id", []interface{}{"1", "2"})).
+		Where(dbr.And(dbr.Gte("name", "a"), dbr.Lte("name", "m"))).
+		Where(dbr.Neq("name", nil)).
+		Where(dbr.Neq("name", "z")).
+		Build(sess.Dialect, buff)
+
+	query, _ := dbr.InterpolateForDialect(buff.String(), buff.Value(), sess.Dialect)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	err := repo.FindBy(&[]MockObject{}, fb)
+	if err != nil {
+		t.Errorf("Expected response, got error: %s", err.Error())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	t.Run("Return an error for a field that does not exist", func(t *testing.T) {
+		err := repo.FindBy(&[]MockObject{}, FindBy{Filters: []Filter{{Field: "invalid", Operator: OPERATOR_EQ, Value: "a"}}})
+		if err == nil {
+			t.Error("Expected error and got none")
+		}
+	})
+
+	t.Run("Return an error for invalid filter values", func(t *testing.T) {
+		filters := []Filter{
+			{Field: "name", Operator: OPERATOR_IN, Value: "a"},
+			{Field: "name", Operator: OPERATOR_BETWEEN, Value: []interface{}{"a"}},
+			{Field: "name", Operator: OPERATOR_NULL, Value: "true"},
+			{Field: "name", Operator: "like", Value: "a"},
+		}
+
+		for _, f := range filters {
+			err := repo.FindBy(&[]MockObject{}, FindBy{Filters: []Filter{f}})
+			if err == nil {
+				t.Errorf("Expected error for %v and got none", f)
+			}
+		}
+	})
+}
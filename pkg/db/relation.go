@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// RELATION_BELONGS_TO is a relation where the model holds the key of a single related model, e.g. a student
+	// belongs to a school through `schoolId`
+	RELATION_BELONGS_TO = "belongs_to"
+	// RELATION_HAS_MANY is a relation where the related models hold the key of the model, e.g. a student has many
+	// enrollments through `studentId` of the enrollment
+	RELATION_HAS_MANY = "has_many"
+)
+
+// Relation links the models of a repository to the models of another repository
+type Relation struct {
+	// Name is the name the related models are embedded under, e.g. `enrollments`
+	Name string
+	// Type is RELATION_BELONGS_TO or RELATION_HAS_MANY
+	Type string
+	// Model is a prototype of the related model
+	Model      interface{}
+	Repository Repository
+	// ForeignKey is the json name of the property that holds the key.  It is a property of the model for a belongs to
+	// relation and of the related model for a has many relation.
+	ForeignKey string
+}
+
+// Load finds the related models of a slice of models with a single query.  The result has an element for each model:
+// the related model, or nil when there is none, for a belongs to relation and a slice of related models for a has many
+// relation.
+func (rel Relation) Load(ctx context.Context, models interface{}) ([]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(models))
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("relation '%s' must be loaded for a slice", rel.Name)
+	}
+
+	keyField := PRIMARY_KEY
+	relatedKeyField := rel.ForeignKey
+	switch rel.Type {
+	case RELATION_BELONGS_TO:
+		keyField, relatedKeyField = rel.ForeignKey, PRIMARY_KEY
+	case RELATION_HAS_MANY:
+	default:
+		return nil, fmt.Errorf("relation '%s' has an unsupported type '%s'", rel.Name, rel.Type)
+	}
+
+	keys := make([]interface{}, v.Len())
+	values := make([]interface{}, 0, v.Len())
+	for i := range keys {
+		key, err := getKey(v.Index(i), keyField)
+		if err != nil {
+			return nil, err
+		}
+
+		keys[i] = key
+		if key != nil && !containsValue(values, key) {
+			values = append(values, key)
+		}
+	}
+
+	relatedType := reflect.TypeOf(rel.Model)
+	if relatedType.Kind() == reflect.Ptr {
+		relatedType = relatedType.Elem()
+	}
+	related := reflect.New(reflect.SliceOf(relatedType))
+	if len(values) > 0 {
+		fb := FindBy{Filters: []Filter{{Field: relatedKeyField, Operator: OPERATOR_IN, Value: values}}}
+		if err := FindByContext(ctx, rel.Repository, related.Interface(), fb); err != nil {
+			return nil, err
+		}
+	}
+
+	grouped := make(map[interface{}]reflect.Value)
+	for i := 0; i < related.Elem().Len(); i++ {
+		item := related.Elem().Index(i)
+		key, err := getKey(item, relatedKeyField)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := grouped[key]; !ok {
+			grouped[key] = reflect.MakeSlice(reflect.SliceOf(relatedType), 0, 1)
+		}
+		grouped[key] = reflect.Append(grouped[key], item)
+	}
+
+	loaded := make([]interface{}, len(keys))
+	for i, key := range keys {
+		items, ok := grouped[key]
+		switch {
+		case rel.Type == RELATION_BELONGS_TO && ok:
+			loaded[i] = items.Index(0).Interface()
+		case rel.Type == RELATION_HAS_MANY && ok:
+			loaded[i] = items.Interface()
+		case rel.Type == RELATION_HAS_MANY:
+			loaded[i] = reflect.MakeSlice(reflect.SliceOf(relatedType), 0, 0).Interface()
+		}
+	}
+
+	return loaded, nil
+}
+
+// getKey returns the value of the property with the json name as a driver.Value so that keys of different types, e.g.
+// an int and a types.NullInt, can be compared and used as a map key.  Nil is returned for a null value.
+func getKey(v reflect.Value, field string) (interface{}, error) {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("property '%s' does not exist", field)
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] != field {
+			continue
+		}
+
+		return driver.DefaultParameterConverter.ConvertValue(v.Field(i).Interface())
+	}
+
+	return nil, fmt.Errorf("property '%s' does not exist", field)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+type MockStudent struct {
+	Id       string           `json:"id" db:"id" structs:"id"`
+	SchoolId types.NullString `json:"schoolId" db:"school_id" structs:"school_id,omitnested"`
+}
+
+type MockEnrollment struct {
+	Id        string `json:"id" db:"id" structs:"id"`
+	StudentId string `json:"studentId" db:"student_id" structs:"student_id"`
+}
+
+func TestRelation_Load(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	students := []MockStudent{
+		{Id: "1", SchoolId: types.NewNullString("a", true)},
+		{Id: "2", SchoolId: types.NewNullString("a", true)},
+		{Id: "3"},
+	}
+
+	t.Run("Load the model each model belongs to", func(t *testing.T) {
+		school := Relation{
+			Name:       "school",
+			Type:       RELATION_BELONGS_TO,
+			Model:      MockObject{},
+			Repository: NewRepository(sess, structs.Helper{}, "school"),
+			ForeignKey: "schoolId",
+		}
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM school WHERE ("id" IN ('a'))`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("a", "school"))
+
+		loaded, err := school.Load(context.Background(), students)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		expected := []interface{}{MockObject{"a", "school"}, MockObject{"a", "school"}, nil}
+		if !reflect.DeepEqual(loaded, expected) {
+			t.Errorf("Expected %v, got %v", expected, loaded)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Load the models each model has", func(t *testing.T) {
+		enrollments := Relation{
+			Name:       "enrollments",
+			Type:       RELATION_HAS_MANY,
+			Model:      MockEnrollment{},
+			Repository: NewRepository(sess, structs.Helper{}, "enrollment"),
+			ForeignKey: "studentId",
+		}
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM enrollment WHERE ("student_id" IN ('1','2','3'))`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "student_id"}).AddRow("x", "1").AddRow("y", "1").AddRow("z", "3"))
+
+		loaded, err := enrollments.Load(context.Background(), &students)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		expected := []interface{}{
+			[]MockEnrollment{{"x", "1"}, {"y", "1"}},
+			[]MockEnrollment{},
+			[]MockEnrollment{{"z", "3"}},
+		}
+		if !reflect.DeepEqual(loaded, expected) {
+			t.Errorf("Expected %v, got %v", expected, loaded)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Return an error for a foreign key that does not exist", func(t *testing.T) {
+		invalid := Relation{Name: "school", Type: RELATION_BELONGS_TO, Model: MockObject{}, ForeignKey: "invalid"}
+		if _, err := invalid.Load(context.Background(), students); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("Return an error for an unsupported type", func(t *testing.T) {
+		invalid := Relation{Name: "school", Type: "invalid", Model: MockObject{}, ForeignKey: "schoolId"}
+		if _, err := invalid.Load(context.Background(), students); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
@@ -1,13 +1,24 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gocraft/dbr"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
+// Repository is the interface the handlers need to load and save a model.  The other features of BaseRepository are
+// split into optional interfaces, e.g. ContextRepository or BatchRepository, so a repository only has to implement the
+// ones it supports.  Callers check for them with a type assertion:
+//
+//	if upserter, ok := repository.(db.Upserter); ok {
+//		inserted, err = upserter.Upsert(object, []string{"sis_id"}, []string{"name"})
+//	}
 type Repository interface {
 	Find(object interface{}, id string) error
 	FindOneBy(object interface{}, fb FindBy) error
@@ -18,33 +29,211 @@ type Repository interface {
 	Count(object interface{}, fb FindBy) (int, error)
 }
 
+// ContextRepository is implemented by repositories whose queries are cancelled when the context is done
+type ContextRepository interface {
+	Repository
+	FindContext(ctx context.Context, object interface{}, id string) error
+	FindOneByContext(ctx context.Context, object interface{}, fb FindBy) error
+	FindByContext(ctx context.Context, objects interface{}, fb FindBy) error
+	CreateContext(ctx context.Context, object interface{}) error
+	UpdateContext(ctx context.Context, object interface{}) error
+	DeleteContext(ctx context.Context, object interface{}) error
+	CountContext(ctx context.Context, object interface{}, fb FindBy) (int, error)
+}
+
+// FieldsUpdater is implemented by repositories that can save only some of the columns of a model
+type FieldsUpdater interface {
+	UpdateFields(object interface{}, fields []string) error
+	UpdateFieldsContext(ctx context.Context, object interface{}, fields []string) error
+}
+
+// CursorRepository is implemented by repositories that support keyset pagination
+type CursorRepository interface {
+	FindByCursor(objects interface{}, fb FindBy, cursor Cursor) error
+	FindByCursorContext(ctx context.Context, objects interface{}, fb FindBy, cursor Cursor) error
+}
+
+// SoftDeleteRepository is implemented by repositories that can restore and purge soft deleted models
+type SoftDeleteRepository interface {
+	FindWithDeleted(object interface{}, id string) error
+	FindWithDeletedContext(ctx context.Context, object interface{}, id string) error
+	Restore(object interface{}) error
+	RestoreContext(ctx context.Context, object interface{}) error
+	Purge(object interface{}) error
+	PurgeContext(ctx context.Context, object interface{}) error
+}
+
+// BatchRepository is implemented by repositories that save a slice of models inside of a single transaction
+type BatchRepository interface {
+	CreateMany(objects interface{}) error
+	CreateManyContext(ctx context.Context, objects interface{}) error
+	UpdateMany(objects interface{}) error
+	UpdateManyContext(ctx context.Context, objects interface{}) error
+	UpdateFieldsMany(objects interface{}, fields [][]string) error
+	UpdateFieldsManyContext(ctx context.Context, objects interface{}, fields [][]string) error
+	DeleteMany(objects interface{}) error
+	DeleteManyContext(ctx context.Context, objects interface{}) error
+}
+
+// Upserter is implemented by repositories that can insert a model or update the row it conflicts with
+type Upserter interface {
+	Upsert(object interface{}, conflictColumns []string, updateColumns []string) (bool, error)
+	UpsertContext(ctx context.Context, object interface{}, conflictColumns []string, updateColumns []string) (bool, error)
+}
+
+// TxRepository is implemented by repositories that can run their queries inside of a transaction
+type TxRepository interface {
+	InTx(tx *Tx) Repository
+	WithTx(ctx context.Context, fn func(Repository) error) error
+}
+
+const PRIMARY_KEY = "id"
+
+const (
+	SORT_ASC  = "asc"
+	SORT_DESC = "desc"
+)
+
 type FindBy struct {
 	Conditions map[string]interface{}
 	Search     map[string]interface{}
-	OrderBy    map[string]interface{}
-	Limit      uint64
-	Offset     uint64
+	Filters    []Filter
+	// OrderBy is kept for backwards compatibility, map iteration order is random so the fields are ordered
+	// alphabetically.  Sort takes precedence when it is set.
+	OrderBy map[string]interface{}
+	Sort    []Sort
+	Limit   uint64
+	Offset  uint64
+	// WithDeleted includes the rows of soft deletable models that have been deleted
+	WithDeleted bool
+	// Fields are the json names of the struct members to load, the other members are left empty.  All of the columns
+	// are loaded when it is empty.
+	Fields []string
+}
+
+// Sort is the direction that a single field is ordered by.  The field is the json name of the struct member.
+type Sort struct {
+	Field     string
+	Direction string
+}
+
+// Sorting returns the sort order that will be applied to the query.  The primary key is added as a tie-breaker when the
+// query is sorted or paged so that the order of the rows is stable between requests.
+func (fb FindBy) Sorting() []Sort {
+	sorting := make([]Sort, 0, len(fb.Sort)+1)
+	if len(fb.Sort) > 0 {
+		sorting = append(sorting, fb.Sort...)
+	} else {
+		fields := make([]string, 0, len(fb.OrderBy))
+		for f := range fb.OrderBy {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+
+		for _, f := range fields {
+			sorting = append(sorting, Sort{Field: f, Direction: fmt.Sprintf("%v", fb.OrderBy[f])})
+		}
+	}
+
+	if len(sorting) == 0 && fb.Limit == 0 && fb.Offset == 0 {
+		return sorting
+	}
+
+	for _, s := range sorting {
+		if s.Field == PRIMARY_KEY {
+			return sorting
+		}
+	}
+
+	return append(sorting, Sort{Field: PRIMARY_KEY, Direction: SORT_ASC})
 }
 
 type BaseRepository struct {
 	Db    *dbr.Session
 	Sh    structs.Helper
 	Table string
+	// Timeout is the default maximum duration of each query.  Zero means the queries only end when their context does.
+	Timeout time.Duration
+	tx      *Tx
 }
 
 func NewRepository(db *dbr.Session, sh structs.Helper, table string) Repository {
-	return &BaseRepository{db, sh, table}
+	return &BaseRepository{Db: db, Sh: sh, Table: table}
+}
+
+// NewRepositoryWithTimeout instantiates a repository where every query is cancelled once the timeout has passed
+func NewRepositoryWithTimeout(db *dbr.Session, sh structs.Helper, table string, timeout time.Duration) Repository {
+	return &BaseRepository{Db: db, Sh: sh, Table: table, Timeout: timeout}
+}
+
+// InTx returns a copy of the repository that runs all of its queries inside of the transaction.  It is used to make
+// changes to several repositories atomic:
+//
+//	db.Transaction(ctx, sess, func(tx *db.Tx) error {
+//		if err := parents.InTx(tx).Create(parent); err != nil {
+//			return err
+//		}
+//		return children.InTx(tx).Create(child)
+//	})
+func (r BaseRepository) InTx(tx *Tx) Repository {
+	r.tx = tx
+
+	return &r
+}
+
+// WithTx runs fn with a copy of the repository bound to a transaction that is committed when fn returns nil and rolled
+// back otherwise.  When the repository is already bound to a transaction, fn runs inside of a savepoint instead.
+func (r BaseRepository) WithTx(ctx context.Context, fn func(Repository) error) error {
+	if r.tx != nil {
+		return r.tx.Savepoint(func(tx *Tx) error {
+			return fn(r.InTx(tx))
+		})
+	}
+
+	return Transaction(ctx, r.Db, func(tx *Tx) error {
+		return fn(r.InTx(tx))
+	})
+}
+
+// runner returns the transaction the repository is bound to or the session if there isn't one
+func (r BaseRepository) runner() dbr.SessionRunner {
+	if r.tx != nil {
+		return r.tx.Tx
+	}
+
+	return r.Db
 }
 
 func (r BaseRepository) Find(object interface{}, id string) error {
+	return r.FindContext(context.Background(), object, id)
+}
+
+func (r BaseRepository) FindContext(ctx context.Context, object interface{}, id string) error {
+	return r.find(ctx, object, id, false)
+}
+
+// find loads the model with the id.  Soft deleted rows are only loaded when withDeleted is true.
+func (r BaseRepository) find(ctx context.Context, object interface{}, id string, withDeleted bool) error {
 	if err := r.IsPointer(object); err != nil {
 		return err
 	}
 
-	return r.Db.Select("*").From(r.Table).Where("id = ?", id).Limit(1).LoadOne(object)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := r.runner().Select("*").From(r.Table).Where("id = ?", id)
+	if column, ok := GetSoftDeleteColumn(object); ok && !withDeleted {
+		query = query.Where(dbr.Eq(column, nil))
+	}
+
+	return query.Limit(1).LoadOneContext(ctx, object)
 }
 
 func (r BaseRepository) FindOneBy(object interface{}, fb FindBy) error {
+	return r.FindOneByContext(context.Background(), object, fb)
+}
+
+func (r BaseRepository) FindOneByContext(ctx context.Context, object interface{}, fb FindBy) error {
 	if err := r.IsPointer(object); err != nil {
 		return err
 	}
@@ -56,10 +245,17 @@ func (r BaseRepository) FindOneBy(object interface{}, fb FindBy) error {
 
 	query = query.Limit(1) // ensure limit is 1
 
-	return query.LoadOne(object)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return query.LoadOneContext(ctx, object)
 }
 
 func (r BaseRepository) FindBy(objects interface{}, fb FindBy) error {
+	return r.FindByContext(context.Background(), objects, fb)
+}
+
+func (r BaseRepository) FindByContext(ctx context.Context, objects interface{}, fb FindBy) error {
 	if err := r.IsPointer(objects); err != nil {
 		return err
 	}
@@ -74,35 +270,217 @@ func (r BaseRepository) FindBy(objects interface{}, fb FindBy) error {
 		return err
 	}
 
-	_, err = query.Load(objects)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err = query.LoadContext(ctx, objects)
 
 	return err
 }
 
+// FindByCursor loads a page of objects using keyset pagination.  Instead of an offset, the rows after (or before) the
+// cursor are returned in the order of fb.Sorting().  Offset is ignored and the sorted fields can't be nullable, see
+// ValidateCursorSorting.
+func (r BaseRepository) FindByCursor(objects interface{}, fb FindBy, cursor Cursor) error {
+	return r.FindByCursorContext(context.Background(), objects, fb, cursor)
+}
+
+func (r BaseRepository) FindByCursorContext(ctx context.Context, objects interface{}, fb FindBy, cursor Cursor) error {
+	if err := r.IsPointer(objects); err != nil {
+		return err
+	}
+
+	object, err := r.getSliceElementType(objects)
+	if err != nil {
+		return err
+	}
+
+	sorting := fb.Sorting()
+	if len(sorting) == 0 {
+		sorting = []Sort{{Field: PRIMARY_KEY, Direction: SORT_ASC}}
+	}
+
+	if len(cursor.Values) > 0 && len(cursor.Values) != len(sorting) {
+		return errors.New("cursor does not match the sort order")
+	}
+
+	if err := ValidateCursorSorting(object, sorting); err != nil {
+		return err
+	}
+
+	// rows before the cursor are loaded in the opposite order and then reversed
+	querySorting := make([]Sort, len(sorting))
+	for i, s := range sorting {
+		querySorting[i] = s
+		if cursor.Before {
+			querySorting[i].Direction = SORT_ASC
+			if s.Direction == SORT_ASC {
+				querySorting[i].Direction = SORT_DESC
+			}
+		}
+	}
+
+	fb.Sort = querySorting
+	fb.OrderBy = nil
+	fb.Offset = 0
+
+	query, err := r.buildQuery(object, fb, false, true)
+	if err != nil {
+		return err
+	}
+
+	if len(cursor.Values) > 0 {
+		columnMap, err := r.Sh.GetTagMap(object, "json", "db")
+		if err != nil {
+			return err
+		}
+
+		columns := make([]string, len(sorting))
+		for i, s := range sorting {
+			columns[i], _ = getSortColumn(columnMap, s.Field)
+		}
+
+		if cursor.Values, err = getCursorColumnValues(object, sorting, cursor); err != nil {
+			return err
+		}
+
+		query = query.Where(keysetCondition(columns, sorting, cursor))
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err = query.LoadContext(ctx, objects); err != nil {
+		return err
+	}
+
+	if cursor.Before {
+		reverseSlice(objects)
+	}
+
+	return nil
+}
+
 func (r BaseRepository) Create(object interface{}) error {
+	return r.CreateContext(context.Background(), object)
+}
+
+func (r BaseRepository) CreateContext(ctx context.Context, object interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	columns := r.Sh.GetTagValues(object, "db")
-	_, err := r.Db.InsertInto(r.Table).Columns(columns...).Record(object).Exec()
+	_, err := r.runner().InsertInto(r.Table).Columns(columns...).Record(object).ExecContext(ctx)
 
 	return err
 }
 
 func (r BaseRepository) Update(object interface{}) error {
+	return r.UpdateContext(context.Background(), object)
+}
+
+// UpdateContext saves the object.  When the object has a VERSION_TAG column the row is only updated if the version is
+// unchanged and the new version is set on the object when it is passed as a pointer.
+func (r BaseRepository) UpdateContext(ctx context.Context, object interface{}) error {
 	objectMap := r.Sh.GetMapByTag(object, "structs")
 
-	_, err := r.Db.Update(r.Table).SetMap(objectMap).Where("id = ?", objectMap["id"]).Exec()
+	return r.update(ctx, object, objectMap["id"], objectMap)
+}
 
-	return err
+// UpdateFields only saves the columns of the named struct members, e.g. the fields returned by
+// validation.DecodeRequestFields, so concurrent changes to the other columns are not overwritten.
+func (r BaseRepository) UpdateFields(object interface{}, fields []string) error {
+	return r.UpdateFieldsContext(context.Background(), object, fields)
 }
 
-func (r BaseRepository) Delete(object interface{}) error {
+func (r BaseRepository) UpdateFieldsContext(ctx context.Context, object interface{}, fields []string) error {
 	objectMap := r.Sh.GetMapByTag(object, "structs")
 
-	_, err := r.Db.DeleteFrom(r.Table).Where("id = ?", objectMap["id"]).Exec()
+	t := reflect.Indirect(reflect.ValueOf(object)).Type()
+	changes := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("%s is not a part of %s", name, t.String())
+		}
 
-	return err
+		key := strings.Split(field.Tag.Get("structs"), ",")[0]
+		if key == "" {
+			key = field.Name
+		}
+
+		value, ok := objectMap[key]
+		if !ok {
+			return fmt.Errorf("%s of %s cannot be updated", name, t.String())
+		}
+
+		changes[key] = value
+	}
+
+	return r.update(ctx, object, objectMap["id"], changes)
+}
+
+// update sets the columns in the map on the row with the id
+func (r BaseRepository) update(ctx context.Context, object interface{}, id interface{}, objectMap map[string]interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := r.runner().Update(r.Table).Where("id = ?", id)
+
+	version, ok := getVersionField(object)
+	if !ok {
+		if len(objectMap) == 0 {
+			return nil
+		}
+
+		_, err := query.SetMap(objectMap).ExecContext(ctx)
+		return err
+	}
+
+	current, err := version.current()
+	if err != nil {
+		return err
+	}
+
+	next, err := version.next()
+	if err != nil {
+		return err
+	}
+
+	objectMap[version.key] = next
+	result, err := query.Where(dbr.Eq(version.column, current)).SetMap(objectMap).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return &ConflictError{Table: r.Table, Id: id}
+	}
+
+	return version.set(next)
+}
+
+// Delete removes the model.  Soft deletable models are only marked as deleted, see SOFT_DELETE_TAG, and versioned
+// models are only removed when the row still has the version they were loaded with, see VERSION_TAG.
+func (r BaseRepository) Delete(object interface{}) error {
+	return r.DeleteContext(context.Background(), object)
+}
+
+func (r BaseRepository) DeleteContext(ctx context.Context, object interface{}) error {
+	if column, ok := GetSoftDeleteColumn(object); ok {
+		return r.softDelete(ctx, object, column)
+	}
+
+	return r.PurgeContext(ctx, object)
 }
 
 func (r BaseRepository) Count(object interface{}, fb FindBy) (int, error) {
+	return r.CountContext(context.Background(), object, fb)
+}
+
+func (r BaseRepository) CountContext(ctx context.Context, object interface{}, fb FindBy) (int, error) {
 	if reflect.ValueOf(object).Kind() != reflect.Struct {
 		return 0, errors.New("object not a struct")
 	}
@@ -111,10 +489,13 @@ func (r BaseRepository) Count(object interface{}, fb FindBy) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	outerQuery := r.Db.Select("COUNT(*)").From(query.As("count"))
+	outerQuery := r.runner().Select("COUNT(*)").From(query.As("count"))
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	count := 0
-	_, err = outerQuery.Load(&count)
+	_, err = outerQuery.LoadContext(ctx, &count)
 	if err != nil {
 		return 0, err
 	}
@@ -122,6 +503,15 @@ func (r BaseRepository) Count(object interface{}, fb FindBy) (int, error) {
 	return count, nil
 }
 
+// withTimeout adds the repository timeout to the context when one is configured
+func (r BaseRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.Timeout > 0 {
+		return context.WithTimeout(ctx, r.Timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
 func (r BaseRepository) IsPointer(object interface{}) error {
 	v := reflect.ValueOf(object)
 
@@ -150,7 +540,16 @@ func (r BaseRepository) buildQuery(object interface{}, fb FindBy, addOffset bool
 		return nil, err
 	}
 
-	query := r.Db.Select("*").From(r.Table)
+	columns, err := getSelectColumns(columnMap, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.runner().Select(columns...).From(r.Table)
+
+	if column, ok := GetSoftDeleteColumn(object); ok && !fb.WithDeleted {
+		query = query.Where(dbr.Eq(column, nil))
+	}
 
 	for f, v := range fb.Conditions {
 		query = query.Where(columnMap[f]+" = ?", v)
@@ -165,8 +564,27 @@ func (r BaseRepository) buildQuery(object interface{}, fb FindBy, addOffset bool
 		query.WhereCond = append(query.WhereCond, dbr.Like(columnMap[f], fmt.Sprintf("%%%v%%", v)))
 	}
 
-	for f, v := range fb.OrderBy {
-		query = query.OrderDir(columnMap[f], v == "asc")
+	for _, f := range fb.Filters {
+		column, ok := columnMap[f.Field]
+		if !ok {
+			return nil, fmt.Errorf("property '%s' does not exist", f.Field)
+		}
+
+		cond, err := f.condition(column)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.Where(cond)
+	}
+
+	for _, s := range fb.Sorting() {
+		column, ok := getSortColumn(columnMap, s.Field)
+		if !ok {
+			return nil, fmt.Errorf("property '%s' does not exist", s.Field)
+		}
+
+		query = query.OrderDir(column, s.Direction == SORT_ASC)
 	}
 
 	if addOffset && fb.Offset != 0 {
@@ -179,3 +597,40 @@ func (r BaseRepository) buildQuery(object interface{}, fb FindBy, addOffset bool
 
 	return query, nil
 }
+
+// getSelectColumns returns the columns of FindBy.Fields along with the primary key and the sorted columns, which are
+// needed for the links and the cursors of a collection.  All of the columns are selected when there are no fields.
+func getSelectColumns(columnMap map[string]string, fb FindBy) ([]string, error) {
+	if len(fb.Fields) == 0 {
+		return []string{"*"}, nil
+	}
+
+	fields := append([]string{PRIMARY_KEY}, fb.Fields...)
+	for _, s := range fb.Sorting() {
+		fields = append(fields, s.Field)
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		column, ok := getSortColumn(columnMap, f)
+		if !ok {
+			return nil, fmt.Errorf("property '%s' does not exist", f)
+		}
+
+		if !contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns, nil
+}
+
+// getSortColumn returns the column for the json field.  The primary key is always sortable even if it isn't tagged.
+func getSortColumn(columnMap map[string]string, field string) (string, bool) {
+	column, ok := columnMap[field]
+	if !ok && field == PRIMARY_KEY {
+		return PRIMARY_KEY, true
+	}
+
+	return column, ok
+}
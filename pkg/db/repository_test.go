@@ -1,15 +1,20 @@
 package db
 
 import (
+	"context"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gocraft/dbr"
 	"github.com/gocraft/dbr/dialect"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockObject struct {
@@ -140,7 +145,7 @@ func TestBaseRepository_FindOneBy(t *testing.T) {
 		query.WhereCond = append(query.WhereCond, dbr.Like(columnMap[f], fmt.Sprintf("%%%v%%", v)))
 	}
 
-	query.OrderDir("name", true).Offset(10).Limit(1).Build(sess.Dialect, buff)
+	query.OrderDir("name", true).OrderDir("id", true).Offset(10).Limit(1).Build(sess.Dialect, buff)
 
 	rows := sqlmock.NewRows([]string{"id", "name"}).
 		AddRow(expectedResult.Id, expectedResult.Name)
@@ -424,3 +429,385 @@ func TestBaseRepository_Update(t *testing.T) {
 		t.Errorf("Did not expect error and got: %s", err)
 	}
 }
+
+func TestFindBy_Sorting(t *testing.T) {
+	t.Run("Sort takes precedence over OrderBy", func(t *testing.T) {
+		fb := FindBy{
+			Sort:    []Sort{{"name", SORT_DESC}},
+			OrderBy: map[string]interface{}{"other": SORT_ASC},
+		}
+
+		expected := []Sort{{"name", SORT_DESC}, {PRIMARY_KEY, SORT_ASC}}
+		if !reflect.DeepEqual(fb.Sorting(), expected) {
+			t.Errorf("Expected %v, got %v", expected, fb.Sorting())
+		}
+	})
+
+	t.Run("OrderBy is sorted alphabetically", func(t *testing.T) {
+		fb := FindBy{
+			OrderBy: map[string]interface{}{"b": SORT_ASC, "a": SORT_DESC, "c": SORT_ASC},
+		}
+
+		expected := []Sort{{"a", SORT_DESC}, {"b", SORT_ASC}, {"c", SORT_ASC}, {PRIMARY_KEY, SORT_ASC}}
+		if !reflect.DeepEqual(fb.Sorting(), expected) {
+			t.Errorf("Expected %v, got %v", expected, fb.Sorting())
+		}
+	})
+
+	t.Run("The primary key is not duplicated", func(t *testing.T) {
+		fb := FindBy{
+			Sort: []Sort{{PRIMARY_KEY, SORT_DESC}},
+		}
+
+		expected := []Sort{{PRIMARY_KEY, SORT_DESC}}
+		if !reflect.DeepEqual(fb.Sorting(), expected) {
+			t.Errorf("Expected %v, got %v", expected, fb.Sorting())
+		}
+	})
+
+	t.Run("The primary key is added when paging", func(t *testing.T) {
+		expected := []Sort{{PRIMARY_KEY, SORT_ASC}}
+		if !reflect.DeepEqual(FindBy{Limit: 10}.Sorting(), expected) {
+			t.Errorf("Expected %v, got %v", expected, FindBy{Limit: 10}.Sorting())
+		}
+
+		if len(FindBy{}.Sorting()) != 0 {
+			t.Errorf("Expected no sort, got %v", FindBy{}.Sorting())
+		}
+	})
+}
+
+func TestBaseRepository_FindByCursor(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	table := "resource"
+	repo := NewRepository(sess, structs.Helper{}, table).(*BaseRepository)
+
+	// test pointer error
+	err := repo.FindByCursor([]MockObject{}, FindBy{}, Cursor{})
+	if err == nil {
+		t.Error("Expected pointer error and got none")
+	}
+
+	fb := FindBy{
+		Sort:  []Sort{{"name", SORT_DESC}},
+		Limit: 10,
+	}
+
+	// test cursor that doesn't match the sort
+	err = repo.FindByCursor(&[]MockObject{}, fb, Cursor{Values: []interface{}{"a"}})
+	if err == nil {
+		t.Error("Expected cursor error and got none")
+	}
+
+	t.Run("Load the rows after the cursor", func(t *testing.T) {
+		buff := dbr.NewBuffer()
+		sess.Select("*").From(table).
+			Where(dbr.Or(
+				dbr.And(dbr.Lt("name", "b")),
+				dbr.And(dbr.Eq("name", "b"), dbr.Gt("id", "2")),
+			)).
+			OrderDir("name", false).
+			OrderDir("id", true).
+			Limit(10).
+			Build(sess.Dialect, buff)
+
+		query, _ := dbr.InterpolateForDialect(buff.String(), buff.Value(), sess.Dialect)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("3", "a"))
+
+		objects := []MockObject{}
+		err := repo.FindByCursor(&objects, fb, Cursor{Values: []interface{}{"b", "2"}})
+		if err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+
+		if len(objects) != 1 {
+			t.Errorf("Expected 1 object, got %d", len(objects))
+		}
+	})
+
+	t.Run("Load the rows before the cursor in the original order", func(t *testing.T) {
+		buff := dbr.NewBuffer()
+		sess.Select("*").From(table).
+			Where(dbr.Or(
+				dbr.And(dbr.Gt("name", "b")),
+				dbr.And(dbr.Eq("name", "b"), dbr.Lt("id", "2")),
+			)).
+			OrderDir("name", true).
+			OrderDir("id", false).
+			Limit(10).
+			Build(sess.Dialect, buff)
+
+		query, _ := dbr.InterpolateForDialect(buff.String(), buff.Value(), sess.Dialect)
+		rows := sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "c").AddRow("4", "d")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+
+		objects := []MockObject{}
+		err := repo.FindByCursor(&objects, fb, Cursor{Values: []interface{}{"b", "2"}, Before: true})
+		if err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+
+		expected := []MockObject{{"4", "d"}, {"1", "c"}}
+		if !reflect.DeepEqual(objects, expected) {
+			t.Errorf("Expected %v, got %v", expected, objects)
+		}
+	})
+}
+
+func TestBaseRepository_FindByCursorTypes(t *testing.T) {
+	type event struct {
+		Id       string             `json:"id" db:"id"`
+		Name     types.NullString   `json:"name" db:"name"`
+		StartsAt types.Datetime     `json:"startsAt" db:"starts_at"`
+		EndsAt   types.NullDatetime `json:"endsAt" db:"ends_at"`
+	}
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+	repo := NewRepository(sess, structs.Helper{}, "event").(*BaseRepository)
+
+	t.Run("Reject a sort by a nullable field", func(t *testing.T) {
+		for _, field := range []string{"name", "endsAt"} {
+			err := repo.FindByCursor(&[]event{}, FindBy{Sort: []Sort{{field, SORT_ASC}}}, Cursor{})
+			if err == nil || !strings.Contains(err.Error(), "null") {
+				t.Errorf("%s: Expected a nullable error, got %v", field, err)
+			}
+		}
+	})
+
+	t.Run("Compare a time of the cursor as a time", func(t *testing.T) {
+		startsAt := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+		values, _ := GetCursorValues(event{Id: "1", StartsAt: types.Datetime{Time: startsAt}}, []Sort{{"startsAt", SORT_ASC}, {"id", SORT_ASC}})
+		token, _ := json.Marshal(values)
+
+		var decoded []interface{}
+		json.Unmarshal(token, &decoded)
+
+		buff := dbr.NewBuffer()
+		sess.Select("*").From("event").
+			Where(dbr.Or(
+				dbr.And(dbr.Gt("starts_at", startsAt)),
+				dbr.And(dbr.Eq("starts_at", startsAt), dbr.Gt("id", "1")),
+			)).
+			OrderDir("starts_at", true).
+			OrderDir("id", true).
+			Build(sess.Dialect, buff)
+
+		query, _ := dbr.InterpolateForDialect(buff.String(), buff.Value(), sess.Dialect)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		err := repo.FindByCursor(&[]event{}, FindBy{Sort: []Sort{{"startsAt", SORT_ASC}}}, Cursor{Values: decoded})
+		if err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetCursorValues(t *testing.T) {
+	values, err := GetCursorValues(MockObject{"1", "name"}, []Sort{{"name", SORT_ASC}, {"id", SORT_ASC}})
+	if err != nil {
+		t.Errorf("Expected values, got error: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(values, []interface{}{"name", "1"}) {
+		t.Errorf("Unexpected cursor values %v", values)
+	}
+
+	if _, err := GetCursorValues(MockObject{}, []Sort{{"invalid", SORT_ASC}}); err == nil {
+		t.Error("Expected error and got none")
+	}
+}
+
+func TestNewRepositoryWithTimeout(t *testing.T) {
+	db, _, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepositoryWithTimeout(sess, structs.Helper{}, "resource", time.Second)
+
+	baseRepo, ok := repo.(*BaseRepository)
+	if !ok {
+		t.Fatalf("Exepected %s, got %s", reflect.TypeOf(&BaseRepository{}), reflect.TypeOf(repo))
+	}
+
+	if baseRepo.Timeout != time.Second {
+		t.Errorf("Expected timeout %s, got %s", time.Second, baseRepo.Timeout)
+	}
+}
+
+func TestBaseRepository_FindContextCancelled(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("123", "Test Name"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := repo.FindContext(ctx, &MockObject{}, "123")
+	if err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestBaseRepository_Timeout(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepositoryWithTimeout(sess, structs.Helper{}, "resource", 10*time.Millisecond).(*BaseRepository)
+
+	mock.ExpectQuery("SELECT").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("123", "Test Name"))
+
+	start := time.Now()
+	err := repo.FindBy(&[]MockObject{}, FindBy{})
+	if err == nil || time.Since(start) >= time.Second {
+		t.Error("Expected the query to be cancelled by the timeout")
+	}
+
+	mock.ExpectExec("DELETE").WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	start = time.Now()
+	err = repo.DeleteContext(context.Background(), MockObject{Id: "123"})
+	if err == nil || time.Since(start) >= time.Second {
+		t.Error("Expected the query to be cancelled by the timeout")
+	}
+}
+
+func TestBaseRepository_UpdateFields(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	type object struct {
+		Id    string `json:"id" db:"id" structs:"id"`
+		Name  string `json:"name" db:"name" structs:"name"`
+		Other string `json:"other" db:"other" structs:"other"`
+	}
+
+	t.Run("Only set the columns of the fields", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "resource" SET "name" = 'test' WHERE (id = '123')`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.UpdateFields(object{Id: "123", Name: "test", Other: "other"}, []string{"Name"}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Always set the version", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "resource" SET "(name|version)" = ('test'|2), "(name|version)" = ('test'|2) WHERE \(id = '123'\) AND \("version" = 1\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.UpdateFields(&MockVersionObject{Id: "123", Name: "test", Version: 1}, []string{"Name"}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Return an error for a field that does not exist", func(t *testing.T) {
+		if err := repo.UpdateFields(MockObject{Id: "123"}, []string{"Invalid"}); err == nil {
+			t.Error("Expected error and got none")
+		}
+	})
+
+	t.Run("Do nothing without any fields", func(t *testing.T) {
+		if err := repo.UpdateFields(MockObject{Id: "123"}, []string{}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBaseRepository_FindByFields(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	t.Run("Select the fields along with the primary key", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name FROM resource`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("123", "test"))
+
+		objects := []MockObject{}
+		if err := repo.FindBy(&objects, FindBy{Fields: []string{"name"}}); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Select the sorted fields", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name FROM resource ORDER BY name DESC, id ASC`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		fb := FindBy{Fields: []string{"id"}, Sort: []Sort{{Field: "name", Direction: SORT_DESC}}}
+
+		objects := []MockObject{}
+		if err := repo.FindBy(&objects, fb); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Return an error for a field that does not exist", func(t *testing.T) {
+		objects := []MockObject{}
+		if err := repo.FindBy(&objects, FindBy{Fields: []string{"invalid"}}); err == nil || err.Error() != "property 'invalid' does not exist" {
+			t.Errorf("Expected the property to not exist, got %v", err)
+		}
+	})
+}
+
+func TestBaseRepository_OptionalInterfaces(t *testing.T) {
+	var repo Repository = &BaseRepository{}
+
+	if _, ok := repo.(ContextRepository); !ok {
+		t.Error("Expected ContextRepository")
+	}
+	if _, ok := repo.(FieldsUpdater); !ok {
+		t.Error("Expected FieldsUpdater")
+	}
+	if _, ok := repo.(CursorRepository); !ok {
+		t.Error("Expected CursorRepository")
+	}
+	if _, ok := repo.(SoftDeleteRepository); !ok {
+		t.Error("Expected SoftDeleteRepository")
+	}
+	if _, ok := repo.(BatchRepository); !ok {
+		t.Error("Expected BatchRepository")
+	}
+	if _, ok := repo.(Upserter); !ok {
+		t.Error("Expected Upserter")
+	}
+	if _, ok := repo.(TxRepository); !ok {
+		t.Error("Expected TxRepository")
+	}
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/gocraft/dbr"
+)
+
+// SOFT_DELETE_TAG marks the column that records when a model was deleted, e.g.
+//
+//	DeletedAt types.NullDatetime `json:"deletedAt" db:"deleted_at" structs:"deleted_at,omitnested" softdelete:"true"`
+//
+// Models with the tag are never removed by Delete.  The column is set instead and the row is excluded from all of the
+// Find and Count queries unless FindBy.WithDeleted is true.
+const SOFT_DELETE_TAG = "softdelete"
+
+// now is replaced in tests so the deleted timestamp is predictable
+var now = time.Now
+
+// GetSoftDeleteColumn returns the db column tagged with SOFT_DELETE_TAG.  False is returned when the model is not soft
+// deletable.
+func GetSoftDeleteColumn(s interface{}) (string, bool) {
+	fields := structs.Fields(s)
+	for i := range fields {
+		if tag := fields[i].Tag(SOFT_DELETE_TAG); tag == "" || tag == "-" || tag == "false" {
+			continue
+		}
+
+		dbField := strings.Split(fields[i].Tag("db"), ",")[0]
+		if dbField == "" || dbField == "-" {
+			continue
+		}
+
+		return dbField, true
+	}
+
+	return "", false
+}
+
+func (r BaseRepository) FindWithDeleted(object interface{}, id string) error {
+	return r.FindWithDeletedContext(context.Background(), object, id)
+}
+
+// FindWithDeletedContext loads the model with the id even when it has been soft deleted, e.g. to restore it
+func (r BaseRepository) FindWithDeletedContext(ctx context.Context, object interface{}, id string) error {
+	return r.find(ctx, object, id, true)
+}
+
+func (r BaseRepository) Restore(object interface{}) error {
+	return r.RestoreContext(context.Background(), object)
+}
+
+// RestoreContext clears the deleted timestamp of a soft deleted model and returns dbr.ErrNotFound when there is no
+// deleted row to restore.  A versioned model, see VERSION_TAG, is only restored when the row still has the version it
+// was loaded with and the version is changed along with the timestamp, the same as an update.
+func (r BaseRepository) RestoreContext(ctx context.Context, object interface{}) error {
+	column, ok := GetSoftDeleteColumn(object)
+	if !ok {
+		return errors.New("object is not soft deletable")
+	}
+
+	return r.setDeleted(ctx, object, column, nil)
+}
+
+func (r BaseRepository) Purge(object interface{}) error {
+	return r.PurgeContext(context.Background(), object)
+}
+
+// PurgeContext permanently deletes the model even when it is soft deletable and returns dbr.ErrNotFound when there is
+// no row to delete.  A versioned model, see VERSION_TAG, is only deleted when the row still has the version it was
+// loaded with.
+func (r BaseRepository) PurgeContext(ctx context.Context, object interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	id := r.Sh.GetMapByTag(object, "structs")["id"]
+	query := r.runner().DeleteFrom(r.Table).Where("id = ?", id)
+
+	version, ok := getVersionField(object)
+	if !ok {
+		result, err := query.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		return checkRowsAffected(result, 1)
+	}
+
+	current, err := version.current()
+	if err != nil {
+		return err
+	}
+
+	result, err := query.Where(dbr.Eq(version.column, current)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return &ConflictError{Table: r.Table, Id: id}
+	}
+
+	return nil
+}
+
+// softDelete sets the deleted timestamp of the model.  Rows that are already deleted keep their original timestamp and
+// dbr.ErrNotFound is returned when there is no row left to delete.  A versioned model, see VERSION_TAG, is only deleted
+// when the row still has the version it was loaded with and the version is changed along with the timestamp.
+func (r BaseRepository) softDelete(ctx context.Context, object interface{}, column string) error {
+	return r.setDeleted(ctx, object, column, now().UTC())
+}
+
+// setDeleted sets the deleted column of a row that is not deleted, or clears it when deletedAt is nil for a row that
+// is, and increments the version of a versioned model
+func (r BaseRepository) setDeleted(ctx context.Context, object interface{}, column string, deletedAt interface{}) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	id := r.Sh.GetMapByTag(object, "structs")["id"]
+	query := r.runner().
+		Update(r.Table).
+		Set(column, deletedAt).
+		Where("id = ?", id)
+
+	if deletedAt == nil {
+		query = query.Where(dbr.Neq(column, nil))
+	} else {
+		query = query.Where(dbr.Eq(column, nil))
+	}
+
+	version, ok := getVersionField(object)
+	if !ok {
+		result, err := query.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		return checkRowsAffected(result, 1)
+	}
+
+	current, err := version.current()
+	if err != nil {
+		return err
+	}
+
+	next, err := version.next()
+	if err != nil {
+		return err
+	}
+
+	result, err := query.Set(version.column, next).Where(dbr.Eq(version.column, current)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return &ConflictError{Table: r.Table, Id: id}
+	}
+
+	return version.set(next)
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+type MockSoftDeleteObject struct {
+	Id        string             `json:"id" db:"id" structs:"id"`
+	Name      string             `json:"name" db:"name" structs:"name"`
+	DeletedAt types.NullDatetime `json:"deletedAt" db:"deleted_at" structs:"deleted_at,omitnested" softdelete:"true"`
+}
+
+// expectedQuery returns the interpolated statement as a pattern for sqlmock
+func expectedQuery(t *testing.T, sess *dbr.Session, stmt dbr.Builder) string {
+	buff := dbr.NewBuffer()
+	if err := stmt.Build(sess.Dialect, buff); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := dbr.InterpolateForDialect(buff.String(), buff.Value(), sess.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return regexp.QuoteMeta(query)
+}
+
+func TestGetSoftDeleteColumn(t *testing.T) {
+	if column, ok := GetSoftDeleteColumn(MockSoftDeleteObject{}); !ok || column != "deleted_at" {
+		t.Errorf("Expected deleted_at, got '%s'", column)
+	}
+
+	if column, ok := GetSoftDeleteColumn(&MockSoftDeleteObject{}); !ok || column != "deleted_at" {
+		t.Errorf("Expected deleted_at for a pointer, got '%s'", column)
+	}
+
+	if _, ok := GetSoftDeleteColumn(MockObject{}); ok {
+		t.Error("Expected MockObject to not be soft deletable")
+	}
+}
+
+func TestBaseRepository_SoftDelete(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	table := "resource"
+	repo := NewRepository(sess, structs.Helper{}, table).(*BaseRepository)
+
+	deletedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time { return deletedAt }
+	defer func() { now = time.Now }()
+
+	object := MockSoftDeleteObject{Id: "123", Name: "Test Name"}
+
+	t.Run("Delete sets the deleted timestamp", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Update(table).
+			Set("deleted_at", deletedAt).
+			Where("id = ?", object.Id).
+			Where(dbr.Eq("deleted_at", nil)))
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if err := repo.Delete(object); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+	})
+
+	t.Run("Delete returns not found when no row changed", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Update(table).
+			Set("deleted_at", deletedAt).
+			Where("id = ?", object.Id).
+			Where(dbr.Eq("deleted_at", nil)))
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.Delete(object); err != dbr.ErrNotFound {
+			t.Errorf("Expected %v, got %v", dbr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("Find excludes deleted rows", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Select("*").From(table).
+			Where("id = ?", object.Id).
+			Where(dbr.Eq("deleted_at", nil)).
+			Limit(1))
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		if err := repo.Find(&MockSoftDeleteObject{}, object.Id); err != dbr.ErrNotFound {
+			t.Errorf("Expected %v, got %v", dbr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("FindWithDeleted includes deleted rows", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Select("*").From(table).
+			Where("id = ?", object.Id).
+			Limit(1))
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_at"}).
+			AddRow(object.Id, object.Name, deletedAt))
+
+		found := MockSoftDeleteObject{}
+		if err := repo.FindWithDeleted(&found, object.Id); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+
+		if found.Id != object.Id || !found.DeletedAt.Valid {
+			t.Errorf("Expected the deleted model, got %+v", found)
+		}
+	})
+
+	t.Run("FindBy and Count exclude deleted rows", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Select("*").From(table).
+			Where(dbr.Eq("deleted_at", nil)))
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		if err := repo.FindBy(&[]MockSoftDeleteObject{}, FindBy{}); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+
+		query = expectedQuery(t, sess, sess.Select("COUNT(*)").From(
+			sess.Select("*").From(table).Where(dbr.Eq("deleted_at", nil)).As("count"),
+		))
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		if _, err := repo.Count(MockSoftDeleteObject{}, FindBy{}); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+	})
+
+	t.Run("FindBy includes deleted rows when asked", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Select("*").From(table))
+		mock.ExpectQuery(query + "$").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		if err := repo.FindBy(&[]MockSoftDeleteObject{}, FindBy{WithDeleted: true}); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+	})
+
+	t.Run("Restore clears the deleted timestamp", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.Update(table).
+			Set("deleted_at", nil).
+			Where("id = ?", object.Id).
+			Where(dbr.Neq("deleted_at", nil)))
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if err := repo.Restore(object); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.Restore(object); err != dbr.ErrNotFound {
+			t.Errorf("Expected %v for a row that isn't deleted, got %v", dbr.ErrNotFound, err)
+		}
+
+		if err := repo.Restore(MockObject{Id: "123"}); err == nil {
+			t.Error("Expected error for a model that is not soft deletable and got none")
+		}
+	})
+
+	t.Run("Purge permanently deletes the row", func(t *testing.T) {
+		query := expectedQuery(t, sess, sess.DeleteFrom(table).Where("id = ?", object.Id))
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if err := repo.Purge(object); err != nil {
+			t.Errorf("Expected response, got error: %s", err.Error())
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
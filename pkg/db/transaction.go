@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gocraft/dbr"
+)
+
+// Tx is a transaction that one or more repositories can be bound to with Repository.InTx
+type Tx struct {
+	*dbr.Tx
+	savepoints int
+}
+
+// Transaction begins a transaction on the session and runs fn inside of it.  The transaction is committed when fn
+// returns nil and rolled back when it returns an error or panics.
+func Transaction(ctx context.Context, sess *dbr.Session, fn func(tx *Tx) error) (err error) {
+	dbrTx, err := sess.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	tx := &Tx{Tx: dbrTx}
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			tx.RollbackUnlessCommitted()
+			panic(rvr)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		tx.RollbackUnlessCommitted()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Savepoint runs fn inside of a savepoint of the transaction.  Only the changes made by fn are rolled back when it
+// returns an error or panics, the transaction itself stays open.
+func (tx *Tx) Savepoint(fn func(tx *Tx) error) (err error) {
+	tx.savepoints++
+	name := fmt.Sprintf("sp_%d", tx.savepoints)
+
+	if _, err = tx.Exec("SAVEPOINT " + name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			tx.Exec("ROLLBACK TO SAVEPOINT " + name)
+			panic(rvr)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if _, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT " + name); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	_, err = tx.Exec("RELEASE SAVEPOINT " + name)
+
+	return err
+}
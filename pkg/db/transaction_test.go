@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+)
+
+func TestTransaction(t *testing.T) {
+	t.Run("Commit when there is no error", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+		sess := conn.NewSession(nil)
+
+		parents := NewRepository(sess, structs.Helper{}, "parent").(*BaseRepository)
+		children := NewRepository(sess, structs.Helper{}, "child").(*BaseRepository)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "parent"`)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "child"`)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := Transaction(context.Background(), sess, func(tx *Tx) error {
+			if err := parents.InTx(tx).Create(MockObject{"1", "parent"}); err != nil {
+				return err
+			}
+
+			return children.InTx(tx).Create(MockObject{"2", "child"})
+		})
+
+		if err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Rollback when there is an error", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+		sess := conn.NewSession(nil)
+
+		repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "resource"`)).WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
+
+		err := repo.WithTx(context.Background(), func(repo Repository) error {
+			return repo.Create(MockObject{"1", "name"})
+		})
+
+		if err == nil || err.Error() != "insert failed" {
+			t.Errorf("Expected the insert error, got: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Rollback and panic again when there is a panic", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+		sess := conn.NewSession(nil)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected the panic to be re-raised")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		Transaction(context.Background(), sess, func(tx *Tx) error {
+			panic("failed")
+		})
+	})
+
+	t.Run("Nested transactions use savepoints", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+		sess := conn.NewSession(nil)
+
+		repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "resource"`)).WillReturnError(errors.New("insert failed"))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "resource"`)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("RELEASE SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.WithTx(context.Background(), func(repo Repository) error {
+			err := repo.(TxRepository).WithTx(context.Background(), func(repo Repository) error {
+				return repo.Create(MockObject{"1", "name"})
+			})
+
+			if err == nil {
+				t.Error("Expected the savepoint to return the insert error")
+			}
+
+			return repo.(TxRepository).WithTx(context.Background(), func(repo Repository) error {
+				return repo.Create(MockObject{"2", "name"})
+			})
+		})
+
+		if err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+)
+
+func (r BaseRepository) Upsert(object interface{}, conflictColumns []string, updateColumns []string) (bool, error) {
+	return r.UpsertContext(context.Background(), object, conflictColumns, updateColumns)
+}
+
+// UpsertContext inserts the model or, when a row with the same conflictColumns already exists, updates the
+// updateColumns of that row.  True is returned when the row was inserted and false when it was updated.  The conflict
+// columns are usually a natural key with a unique index, they are required by Postgres and ignored by MySQL, which uses
+// every unique index of the table.  The row is left as it is when there are no update columns.
+//
+// Updating a row also increments its version, see VERSION_TAG, and restores it when it was soft deleted, see
+// SOFT_DELETE_TAG.  The version isn't checked.  When the object is a pointer and the row already existed, the object is
+// reloaded from the row with the same conflict columns, so it has the id and version of the existing row.
+func (r BaseRepository) UpsertContext(ctx context.Context, object interface{}, conflictColumns []string, updateColumns []string) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	columns := r.Sh.GetTagValues(object, "db")
+	for _, column := range append(conflictColumns[:len(conflictColumns):len(conflictColumns)], updateColumns...) {
+		if !contains(columns, column) {
+			return false, fmt.Errorf("'%s' is not a column of %s", column, r.Table)
+		}
+	}
+
+	d := r.dialect()
+	buff := dbr.NewBuffer()
+	if err := r.runner().InsertInto(r.Table).Columns(columns...).Record(object).Build(d, buff); err != nil {
+		return false, err
+	}
+
+	query := buff.String()
+	switch d {
+	case dialect.PostgreSQL:
+		if len(conflictColumns) == 0 {
+			return false, errors.New("conflict columns are required to upsert with postgres")
+		}
+
+		query += " ON CONFLICT (" + quoteColumns(d, conflictColumns) + ")"
+		if len(updateColumns) == 0 {
+			query += " DO NOTHING"
+		} else {
+			set, err := r.upsertAssignments(d, object, updateColumns, "EXCLUDED.%s")
+			if err != nil {
+				return false, err
+			}
+
+			query += " DO UPDATE SET " + set
+		}
+
+		// xmax is only zero for a row version that was inserted
+		var inserted bool
+		err := r.runner().SelectBySql(query+" RETURNING (xmax = 0)", buff.Value()...).LoadOneContext(ctx, &inserted)
+		if err == dbr.ErrNotFound {
+			return false, r.loadUpserted(ctx, object, conflictColumns)
+		}
+
+		if err != nil || inserted {
+			return inserted, err
+		}
+
+		return false, r.loadUpserted(ctx, object, conflictColumns)
+	case dialect.MySQL:
+		if len(updateColumns) == 0 {
+			updateColumns = columns[:1]
+			query += " ON DUPLICATE KEY UPDATE " + setColumns(d, updateColumns, "%s")
+		} else {
+			set, err := r.upsertAssignments(d, object, updateColumns, "VALUES(%s)")
+			if err != nil {
+				return false, err
+			}
+
+			query += " ON DUPLICATE KEY UPDATE " + set
+		}
+
+		result, err := r.runner().InsertBySql(query, buff.Value()...).ExecContext(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		// one row is affected by an insert, two by an update and none when the row already had the same values
+		rows, err := result.RowsAffected()
+		if err != nil || rows == 1 {
+			return rows == 1, err
+		}
+
+		return false, r.loadUpserted(ctx, object, conflictColumns)
+	}
+
+	return false, errors.New("upsert is only supported for postgres and mysql")
+}
+
+// upsertAssignments returns the assignments of an upsert that updates the row.  The version is incremented, or set to
+// the current time, and the soft delete column is cleared unless they are update columns themselves.
+func (r BaseRepository) upsertAssignments(d dbr.Dialect, object interface{}, updateColumns []string, valueFormat string) (string, error) {
+	set := setColumns(d, updateColumns, valueFormat)
+
+	if version, ok := getVersionField(object); ok && !contains(updateColumns, version.column) {
+		next, err := version.next()
+		if err != nil {
+			return "", err
+		}
+
+		column := d.QuoteIdent(version.column)
+		if t, ok := next.(time.Time); ok {
+			set += ", " + column + " = " + d.EncodeTime(t)
+		} else if d == dialect.PostgreSQL {
+			// an unqualified column would be ambiguous with the excluded row
+			set += ", " + column + " = COALESCE(" + d.QuoteIdent(r.Table) + "." + column + ", 0) + 1"
+		} else {
+			set += ", " + column + " = COALESCE(" + column + ", 0) + 1"
+		}
+	}
+
+	if column, ok := GetSoftDeleteColumn(object); ok && !contains(updateColumns, column) {
+		set += ", " + d.QuoteIdent(column) + " = NULL"
+	}
+
+	return set, nil
+}
+
+// loadUpserted reloads the object from the row with the same conflict columns when it wasn't inserted.  The object is
+// left as it is when it isn't a pointer or there are no conflict columns to find the row by.
+func (r BaseRepository) loadUpserted(ctx context.Context, object interface{}, conflictColumns []string) error {
+	if len(conflictColumns) == 0 || r.IsPointer(object) != nil {
+		return nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(object))
+	query := r.runner().Select("*").From(r.Table)
+	for _, column := range conflictColumns {
+		field, ok := getFieldByDbTag(v, column)
+		if !ok {
+			return fmt.Errorf("'%s' is not a column of %s", column, r.Table)
+		}
+
+		query.Where(dbr.Eq(column, field.Interface()))
+	}
+
+	return query.LoadOneContext(ctx, object)
+}
+
+// getFieldByDbTag returns the struct member with the db column name
+func getFieldByDbTag(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("db"), ",")[0] == column {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// dialect returns the dialect of the session or of the transaction the repository is bound to
+func (r BaseRepository) dialect() dbr.Dialect {
+	if r.tx != nil {
+		return r.tx.Dialect
+	}
+
+	return r.Db.Dialect
+}
+
+// quoteColumns returns the comma separated list of quoted columns
+func quoteColumns(d dbr.Dialect, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.QuoteIdent(column)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// setColumns returns the assignments of an upsert where each column is set to the value format of its quoted name
+func setColumns(d dbr.Dialect, columns []string, valueFormat string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = d.QuoteIdent(column) + " = " + fmt.Sprintf(valueFormat, d.QuoteIdent(column))
+	}
+
+	return strings.Join(set, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+func TestBaseRepository_UpsertPostgres(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+	object := MockObject{Id: "123", Name: "test"}
+
+	tests := []struct {
+		name     string
+		update   []string
+		query    string
+		rows     *sqlmock.Rows
+		inserted bool
+	}{
+		{
+			name:     "Insert a new row",
+			update:   []string{"name"},
+			query:    `ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING (xmax = 0)`,
+			rows:     sqlmock.NewRows([]string{"inserted"}).AddRow(true),
+			inserted: true,
+		},
+		{
+			name:     "Update an existing row",
+			update:   []string{"name"},
+			query:    `ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING (xmax = 0)`,
+			rows:     sqlmock.NewRows([]string{"inserted"}).AddRow(false),
+			inserted: false,
+		},
+		{
+			name:     "Leave an existing row without update columns",
+			query:    `ON CONFLICT ("id") DO NOTHING RETURNING (xmax = 0)`,
+			rows:     sqlmock.NewRows([]string{"inserted"}),
+			inserted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insert := regexp.QuoteMeta(`INSERT INTO "resource" ("id","name") VALUES ('123','test') `)
+			mock.ExpectQuery(insert + regexp.QuoteMeta(tt.query)).WillReturnRows(tt.rows)
+
+			inserted, err := repo.Upsert(object, []string{"id"}, tt.update)
+			if err != nil {
+				t.Fatalf("Did not expect error and got: %s", err)
+			}
+
+			if inserted != tt.inserted {
+				t.Errorf("Expected inserted to be %t", tt.inserted)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("Return an error without conflict columns", func(t *testing.T) {
+		if _, err := repo.Upsert(object, nil, []string{"name"}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("Return an error for a column the model doesn't have", func(t *testing.T) {
+		if _, err := repo.Upsert(object, []string{"id"}, []string{"invalid"}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
+
+func TestBaseRepository_UpsertMySQL(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.MySQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+	object := MockObject{Id: "123", Name: "test"}
+
+	tests := []struct {
+		name     string
+		update   []string
+		query    string
+		rows     int64
+		inserted bool
+	}{
+		{"Insert a new row", []string{"name"}, "ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)", 1, true},
+		{"Update an existing row", []string{"name"}, "ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)", 2, false},
+		{"Leave an existing row without update columns", nil, "ON DUPLICATE KEY UPDATE `id` = `id`", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insert := regexp.QuoteMeta("INSERT INTO `resource` (`id`,`name`) VALUES ('123','test') ")
+			mock.ExpectExec(insert + regexp.QuoteMeta(tt.query)).WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			inserted, err := repo.Upsert(object, []string{"id"}, tt.update)
+			if err != nil {
+				t.Fatalf("Did not expect error and got: %s", err)
+			}
+
+			if inserted != tt.inserted {
+				t.Errorf("Expected inserted to be %t", tt.inserted)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestBaseRepository_UpsertExistingRow(t *testing.T) {
+	type student struct {
+		Id        string             `json:"id" db:"id" structs:"id"`
+		SisId     string             `json:"sisId" db:"sis_id" structs:"sis_id"`
+		Name      string             `json:"name" db:"name" structs:"name"`
+		Version   int                `json:"version" db:"version" structs:"version" version:"true"`
+		DeletedAt types.NullDatetime `json:"deletedAt" db:"deleted_at" structs:"deleted_at,omitnested" softdelete:"true"`
+	}
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	t.Run("Increment the version, restore the row and load its id with postgres", func(t *testing.T) {
+		conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+		repo := NewRepository(conn.NewSession(nil), structs.Helper{}, "student").(*BaseRepository)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT ("sis_id") DO UPDATE SET "name" = EXCLUDED."name", "version" = COALESCE("student"."version", 0) + 1, "deleted_at" = NULL RETURNING (xmax = 0)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM student WHERE ("sis_id" = 'S1')`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "sis_id", "name", "version"}).AddRow("123", "S1", "test", 4))
+
+		object := &student{Id: "new", SisId: "S1", Name: "test"}
+		inserted, err := repo.Upsert(object, []string{"sis_id"}, []string{"name"})
+		if err != nil || inserted {
+			t.Fatalf("Expected an update without error, got %t, %v", inserted, err)
+		}
+
+		if object.Id != "123" || object.Version != 4 {
+			t.Errorf("Expected the id and version of the existing row, got '%s' and %d", object.Id, object.Version)
+		}
+	})
+
+	t.Run("Increment the version, restore the row and load its id with mysql", func(t *testing.T) {
+		conn := &dbr.Connection{DB: db, Dialect: dialect.MySQL, EventReceiver: &dbr.NullEventReceiver{}}
+		repo := NewRepository(conn.NewSession(nil), structs.Helper{}, "student").(*BaseRepository)
+
+		mock.ExpectExec(regexp.QuoteMeta("ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `version` = COALESCE(`version`, 0) + 1, `deleted_at` = NULL")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM student WHERE (`sis_id` = 'S1')")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "sis_id", "name", "version"}).AddRow("123", "S1", "test", 4))
+
+		object := &student{Id: "new", SisId: "S1", Name: "test"}
+		inserted, err := repo.Upsert(object, []string{"sis_id"}, []string{"name"})
+		if err != nil || inserted {
+			t.Fatalf("Expected an update without error, got %t, %v", inserted, err)
+		}
+
+		if object.Id != "123" || object.Version != 4 {
+			t.Errorf("Expected the id and version of the existing row, got '%s' and %d", object.Id, object.Version)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
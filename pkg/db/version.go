@@ -0,0 +1,200 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// VERSION_TAG marks the column used for optimistic concurrency control, e.g.
+//
+//	Version int `json:"version" db:"version" structs:"version" version:"true"`
+//
+// The column can be an integer such as *types.NullInt*, which is incremented, or a timestamp such as
+// *types.NullDatetime*, which is set to the current time.  Update, Delete and Purge only succeed when the column still
+// holds the value the object was loaded with, otherwise a *ConflictError is returned.
+//
+// A timestamp is truncated to whole seconds, the precision of a MySQL DATETIME, so the value in memory matches the
+// value that is loaded next.  The tag can be set to the precision of the column instead, e.g. `version:"1us"` for a
+// DATETIME(6) or a Postgres timestamp.
+const VERSION_TAG = "version"
+
+// DEFAULT_VERSION_PRECISION is the precision of a timestamp version when the tag doesn't have one
+const DEFAULT_VERSION_PRECISION = time.Second
+
+// ConflictError is returned by Update, Delete and Purge when the row was changed or removed since the object was loaded
+type ConflictError struct {
+	Table string
+	Id    interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s '%v' was modified by another request", e.Table, e.Id)
+}
+
+// versionField is the struct member tagged with VERSION_TAG
+type versionField struct {
+	name   string
+	column string
+	key    string
+	value  reflect.Value
+	// precision is what a timestamp version is truncated to
+	precision time.Duration
+}
+
+// getVersionField returns the struct member tagged with VERSION_TAG
+func getVersionField(object interface{}) (versionField, bool) {
+	v := reflect.Indirect(reflect.ValueOf(object))
+	if v.Kind() != reflect.Struct {
+		return versionField{}, false
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get(VERSION_TAG)
+		if tag == "" || tag == "-" || tag == "false" {
+			continue
+		}
+
+		column := strings.Split(t.Field(i).Tag.Get("db"), ",")[0]
+		key := strings.Split(t.Field(i).Tag.Get("structs"), ",")[0]
+		if column == "" || column == "-" || key == "" || key == "-" {
+			continue
+		}
+
+		precision := DEFAULT_VERSION_PRECISION
+		if d, err := time.ParseDuration(tag); err == nil && d > 0 {
+			precision = d
+		}
+
+		return versionField{name: t.Field(i).Name, column: column, key: key, value: v.Field(i), precision: precision}, true
+	}
+
+	return versionField{}, false
+}
+
+// GetVersion returns the value of the member tagged with VERSION_TAG as it is stored in the database, e.g. an int64 or
+// a time.Time, or nil when it is null.  False is returned when the model isn't versioned.
+func GetVersion(object interface{}) (interface{}, bool, error) {
+	version, ok := getVersionField(object)
+	if !ok {
+		return nil, false, nil
+	}
+
+	current, err := version.current()
+
+	return current, true, err
+}
+
+// GetVersionMember returns the name of the struct member tagged with VERSION_TAG.  False is returned when the model
+// isn't versioned.
+func GetVersionMember(object interface{}) (string, bool) {
+	version, ok := getVersionField(object)
+
+	return version.name, ok
+}
+
+// current returns the version the object was loaded with
+func (f versionField) current() (interface{}, error) {
+	value := f.value.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	return value, nil
+}
+
+// next returns the version that replaces the current one
+func (f versionField) next() (interface{}, error) {
+	switch f.value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.value.Int() + 1, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return f.value.Uint() + 1, nil
+	}
+
+	switch base := getBaseType(f.value.Type()); base.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// integer types such as *types.NullInt* are incremented through their value, a null version becomes 1
+		current, err := f.current()
+		if err != nil {
+			return nil, err
+		}
+
+		i, _ := current.(int64)
+
+		return i + 1, nil
+	case reflect.Struct:
+		if base == timeType {
+			return f.nextTime()
+		}
+	}
+
+	return nil, fmt.Errorf("version property '%s' must be an integer or a timestamp", f.column)
+}
+
+// nextTime returns the current time truncated to the precision of the column so that it matches the stored value on the
+// next update.  A version that was set within the same interval is moved forward by the precision so that the new
+// version is always different from the current one.
+func (f versionField) nextTime() (interface{}, error) {
+	next := now().UTC().Truncate(f.precision)
+
+	current, err := f.current()
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := current.(time.Time); ok && !next.After(t) {
+		next = t.UTC().Truncate(f.precision).Add(f.precision)
+	}
+
+	return next, nil
+}
+
+// getBaseType returns the type that a null type stores its value as, e.g. int64 for *types.NullInt* and time.Time for
+// *types.NullDatetime*.  The value is the first member that isn't the Valid flag, following embedded structs.
+func getBaseType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Struct && t != timeType {
+		var member reflect.Type
+		for i := 0; i < t.NumField() && member == nil; i++ {
+			if t.Field(i).Name != "Valid" {
+				member = t.Field(i).Type
+			}
+		}
+
+		if member == nil {
+			return t
+		}
+
+		t = member
+	}
+
+	return t
+}
+
+// set updates the object with the new version when it was passed as a pointer
+func (f versionField) set(version interface{}) error {
+	if !f.value.CanSet() {
+		return nil
+	}
+
+	switch f.value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.value.SetInt(version.(int64))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.value.SetUint(version.(uint64))
+		return nil
+	}
+
+	if scanner, ok := f.value.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(version)
+	}
+
+	f.value.Set(reflect.ValueOf(version))
+
+	return nil
+}
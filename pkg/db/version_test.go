@@ -0,0 +1,266 @@
+package db
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/structs"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"gopkg.in/guregu/null.v3"
+)
+
+type MockVersionObject struct {
+	Id      string `json:"id" db:"id" structs:"id"`
+	Name    string `json:"name" db:"name" structs:"name"`
+	Version int    `json:"version" db:"version" structs:"version" version:"true"`
+}
+
+type MockTimestampVersionObject struct {
+	Id        string             `json:"id" db:"id" structs:"id"`
+	UpdatedAt types.NullDatetime `json:"updatedAt" db:"updated_at" structs:"updated_at,omitnested" version:"true"`
+}
+
+func TestBaseRepository_UpdateVersion(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	t.Run("Increment the version", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "resource" SET .*"version" = 4.* WHERE \(id = '123'\) AND \("version" = 3\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		object := &MockVersionObject{Id: "123", Name: "test", Version: 3}
+		if err := repo.Update(object); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if object.Version != 4 {
+			t.Errorf("Expected version 4, got %d", object.Version)
+		}
+	})
+
+	t.Run("Update an object passed by value", func(t *testing.T) {
+		mock.ExpectExec(`WHERE \(id = '123'\) AND \("version" = 3\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.Update(MockVersionObject{Id: "123", Version: 3}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Return a conflict when the version changed", func(t *testing.T) {
+		mock.ExpectExec(`WHERE \(id = '123'\) AND \("version" = 3\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		object := &MockVersionObject{Id: "123", Version: 3}
+		err := repo.Update(object)
+
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("Expected *ConflictError, got %v", err)
+		}
+
+		if conflict.Id != "123" || object.Version != 3 {
+			t.Errorf("Expected conflict for '123' without changing the version, got '%v' and %d", conflict.Id, object.Version)
+		}
+	})
+
+	t.Run("Set a timestamp version to the current time", func(t *testing.T) {
+		updatedAt := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+		now = func() time.Time { return updatedAt.Add(time.Nanosecond) }
+		defer func() { now = time.Now }()
+
+		mock.ExpectExec(regexp.QuoteMeta(`"updated_at" = '2019-01-02 03:04:05.000000'`) + `.* WHERE \(id = '123'\) AND \("updated_at" IS NULL\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		object := &MockTimestampVersionObject{Id: "123"}
+		if err := repo.Update(object); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if !object.UpdatedAt.Time.Time.Equal(updatedAt) {
+			t.Errorf("Expected %s, got %s", updatedAt, object.UpdatedAt.Time.Time)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBaseRepository_DeleteVersion(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	repo := NewRepository(sess, structs.Helper{}, "resource").(*BaseRepository)
+
+	t.Run("Delete the version the object was loaded with", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "resource" WHERE (id = '123') AND ("version" = 3)`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.Delete(MockVersionObject{Id: "123", Version: 3}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Return a conflict when the version changed", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM "resource" WHERE \(id = '123'\) AND \("version" = 3\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		var conflict *ConflictError
+		if err := repo.Delete(MockVersionObject{Id: "123", Version: 3}); !errors.As(err, &conflict) {
+			t.Errorf("Expected *ConflictError, got %v", err)
+		}
+	})
+
+	t.Run("Change the version of a soft deleted object", func(t *testing.T) {
+		object := &struct {
+			Id        string             `json:"id" db:"id" structs:"id"`
+			Version   int                `json:"version" db:"version" structs:"version" version:"true"`
+			DeletedAt types.NullDatetime `json:"deletedAt" db:"deleted_at" structs:"deleted_at,omitnested" softdelete:"true"`
+		}{Id: "123", Version: 3}
+
+		mock.ExpectExec(`UPDATE "resource" SET .*"version" = 4.* WHERE \(id = '123'\) AND \("deleted_at" IS NULL\) AND \("version" = 3\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.Delete(object); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if object.Version != 4 {
+			t.Errorf("Expected version 4, got %d", object.Version)
+		}
+
+		mock.ExpectExec(`UPDATE "resource" SET .*"version" = 5.* WHERE \(id = '123'\) AND \("deleted_at" IS NOT NULL\) AND \("version" = 4\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.Restore(object); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if object.Version != 5 {
+			t.Errorf("Expected the restore to change the version to 5, got %d", object.Version)
+		}
+
+		mock.ExpectExec(`UPDATE "resource" SET .* WHERE \(id = '123'\) AND \("deleted_at" IS NOT NULL\) AND \("version" = 5\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		var conflict *ConflictError
+		if err := repo.Restore(object); !errors.As(err, &conflict) || object.Version != 5 {
+			t.Errorf("Expected a conflict for a stale version, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	if _, ok, _ := GetVersion(MockObject{}); ok {
+		t.Error("Expected MockObject to not have a version")
+	}
+
+	version, ok, err := GetVersion(MockVersionObject{Version: 3})
+	if !ok || err != nil || version != 3 {
+		t.Errorf("Expected version 3, got %v, %v", version, err)
+	}
+
+	version, ok, err = GetVersion(&MockTimestampVersionObject{})
+	if !ok || err != nil || version != nil {
+		t.Errorf("Expected a null version, got %v, %v", version, err)
+	}
+
+	if name, ok := GetVersionMember(&MockTimestampVersionObject{}); !ok || name != "UpdatedAt" {
+		t.Errorf("Expected UpdatedAt, got %s", name)
+	}
+}
+
+func TestGetVersionField(t *testing.T) {
+	if _, ok := getVersionField(MockObject{}); ok {
+		t.Error("Expected MockObject to not have a version")
+	}
+
+	type invalid struct {
+		Id      string `json:"id" db:"id" structs:"id"`
+		Version string `json:"version" db:"version" structs:"version" version:"true"`
+	}
+
+	field, ok := getVersionField(&invalid{})
+	if !ok {
+		t.Fatal("Expected a version field")
+	}
+
+	if _, err := field.next(); err == nil {
+		t.Error("Expected error for a string version and got none")
+	}
+}
+
+func TestVersionField_Timestamp(t *testing.T) {
+	updatedAt := time.Date(2019, 1, 2, 3, 4, 5, 750000000, time.UTC)
+	now = func() time.Time { return updatedAt }
+	defer func() { now = time.Now }()
+
+	t.Run("Truncate to seconds by default", func(t *testing.T) {
+		field, _ := getVersionField(&MockTimestampVersionObject{})
+		if next, err := field.next(); err != nil || next != updatedAt.Truncate(time.Second) {
+			t.Errorf("Expected %s, got %v, %v", updatedAt.Truncate(time.Second), next, err)
+		}
+	})
+
+	t.Run("Truncate to the precision of the tag", func(t *testing.T) {
+		field, _ := getVersionField(&struct {
+			UpdatedAt types.NullDatetime `json:"updatedAt" db:"updated_at" structs:"updated_at,omitnested" version:"1us"`
+		}{})
+
+		if next, err := field.next(); err != nil || next != updatedAt {
+			t.Errorf("Expected %s, got %v, %v", updatedAt, next, err)
+		}
+	})
+
+	t.Run("Change a version that was set within the same second", func(t *testing.T) {
+		current := updatedAt.Truncate(time.Second)
+		field, _ := getVersionField(&MockTimestampVersionObject{UpdatedAt: types.NullDatetime{Time: null.TimeFrom(current)}})
+
+		if next, err := field.next(); err != nil || next != current.Add(time.Second) {
+			t.Errorf("Expected %s, got %v, %v", current.Add(time.Second), next, err)
+		}
+	})
+}
+
+func TestVersionField_NullInt(t *testing.T) {
+	object := &struct {
+		Id      string        `json:"id" db:"id" structs:"id"`
+		Version types.NullInt `json:"version" db:"version" structs:"version,omitnested" version:"true"`
+	}{Id: "1", Version: types.NewNullInt(4, true)}
+
+	field, _ := getVersionField(object)
+	next, err := field.next()
+	if err != nil || next != int64(5) {
+		t.Fatalf("Expected 5, got %v, %v", next, err)
+	}
+
+	if err := field.set(next); err != nil || object.Version.Int64 != 5 {
+		t.Errorf("Expected the version to be set to 5, got %v, %v", object.Version, err)
+	}
+
+	t.Run("Start a null version at 1", func(t *testing.T) {
+		object.Version = types.NullInt{}
+		next, err := field.next()
+		if err != nil || next != int64(1) {
+			t.Fatalf("Expected 1, got %v, %v", next, err)
+		}
+
+		if err := field.set(next); err != nil || !object.Version.Valid || object.Version.Int64 != 1 {
+			t.Errorf("Expected the version to be set to 1, got %v, %v", object.Version, err)
+		}
+	})
+}
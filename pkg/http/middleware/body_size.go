@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+// MaxBodySize returns 413 when the Content-Length of the request is larger than limit bytes.  The body is also wrapped
+// by validation.LimitReader so a handler gets validation.ErrBodyTooLarge when a body without a Content-Length is.
+func MaxBodySize(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(response.NewErrorResponse(http.StatusRequestEntityTooLarge, fmt.Sprintf("%s. The request body cannot be larger than %d bytes.", http.StatusText(http.StatusRequestEntityTooLarge), limit)))
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = limitedBody{Reader: validation.LimitReader(r.Body, limit), Closer: r.Body}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	t.Run("Return a 413 when the Content-Length is too large", func(t *testing.T) {
+		handler := MaxBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("Next handler should not execute.")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("12345")))
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected HTTP Status Code %d got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+
+		if !strings.Contains(w.Body.String(), `"code":413`) {
+			t.Errorf("Expected an error response, got %s", w.Body.String())
+		}
+	})
+
+	tests := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{"Read a body within the limit", "1234", nil},
+		{"Fail to read a body without a Content-Length that is too large", "12345", validation.ErrBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			var body []byte
+			handler := MaxBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err = ioutil.ReadAll(r.Body)
+			}))
+
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			r.ContentLength = -1
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+
+			if tt.err == nil && string(body) != tt.body {
+				t.Errorf("Expected body %s, got %s", tt.body, body)
+			}
+		})
+	}
+}
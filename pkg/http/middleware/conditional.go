@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+const (
+	ETagHeader        = "ETag"
+	IfMatchHeader     = "If-Match"
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+// bufferedWriter holds the response so it can be inspected before anything is sent to the client
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func newBufferedWriter() *bufferedWriter {
+	return &bufferedWriter{header: http.Header{}, code: http.StatusOK}
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+// ConditionalRequest honors the If-None-Match header using the ETag that the handlers set on their responses.  A GET
+// that matches If-None-Match returns 304 without a body.  The If-Match header of a PATCH or DELETE is checked by the
+// Resource handlers instead, against the version of the model that they save, so nothing can change it in between.  It
+// has to wrap the router, rather than be added with Router.Use, so it sees the ETag of the response.
+func ConditionalRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch := r.Header.Get(IfNoneMatchHeader)
+		if ifNoneMatch == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := newBufferedWriter()
+		next.ServeHTTP(bw, r)
+
+		for k, v := range bw.header {
+			w.Header()[k] = v
+		}
+
+		if bw.code == http.StatusOK && ETagMatches(ifNoneMatch, bw.header.Get(ETagHeader), true) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(bw.code)
+		w.Write(bw.buf.Bytes())
+	})
+}
+
+// ETagMatches returns true when the ETag is in the comma separated list of the header or the header is `*`.  If-Match
+// uses the strong comparison so weak ETags never match it.
+func ETagMatches(header string, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+			etag = strings.TrimPrefix(etag, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testETag = `"abc"`
+
+func newConditionalHandler(t *testing.T, etag string, updated *bool) http.Handler {
+	return ConditionalRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set(ETagHeader, etag)
+			w.Write([]byte(`{"id":"1"}`))
+		case http.MethodPatch:
+			*updated = true
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Fatalf("Unexpected method %s", r.Method)
+		}
+	}))
+}
+
+func TestConditionalRequest_IfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		code        int
+		body        string
+	}{
+		{"Return the body without a header", "", http.StatusOK, `{"id":"1"}`},
+		{"Return a 304 when the ETag matches", testETag, http.StatusNotModified, ""},
+		{"Return a 304 when one of the ETags matches", `"xyz", W/"abc"`, http.StatusNotModified, ""},
+		{"Return the body when the ETag changed", `"xyz"`, http.StatusOK, `{"id":"1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/models/1", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set(IfNoneMatchHeader, tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			newConditionalHandler(t, testETag, new(bool)).ServeHTTP(w, r)
+
+			if w.Code != tt.code {
+				t.Errorf("Expected HTTP Status Code %d got %d", tt.code, w.Code)
+			}
+
+			if w.Body.String() != tt.body {
+				t.Errorf("Expected body %s, got %s", tt.body, w.Body.String())
+			}
+
+			if w.Header().Get(ETagHeader) != testETag {
+				t.Errorf("Expected ETag %s, got %s", testETag, w.Header().Get(ETagHeader))
+			}
+		})
+	}
+}
+
+func TestConditionalRequest_IfMatch(t *testing.T) {
+	t.Run("Leave If-Match to the handler", func(t *testing.T) {
+		r := httptest.NewRequest("PATCH", "/models/1", strings.NewReader(`{"name":"test"}`))
+		r.Header.Set(IfMatchHeader, `"xyz"`)
+		w := httptest.NewRecorder()
+		updated := false
+		newConditionalHandler(t, testETag, &updated).ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK || !updated {
+			t.Errorf("Expected the request to reach the handler, got %d", w.Code)
+		}
+	})
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		etag     string
+		weak     bool
+		expected bool
+	}{
+		{"Match the ETag", testETag, testETag, false, true},
+		{"Match any ETag", "*", testETag, false, true},
+		{"Match one of the ETags", `"xyz", "abc"`, testETag, false, true},
+		{"Do not match a changed ETag", `"xyz"`, testETag, false, false},
+		{"Do not match a weak ETag with the strong comparison", `W/"abc"`, testETag, false, false},
+		{"Match any ETag even when it is weak", "*", `W/"abc"`, false, true},
+		{"Match a weak ETag with the weak comparison", `W/"abc"`, testETag, true, true},
+		{"Do not match without an ETag", "*", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ETagMatches(tt.header, tt.etag, tt.weak) != tt.expected {
+				t.Errorf("Expected %t", tt.expected)
+			}
+		})
+	}
+}
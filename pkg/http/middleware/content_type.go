@@ -3,16 +3,21 @@ package middleware
 import (
 	"encoding/json"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+	"mime"
 	"net/http"
+	"strings"
 )
 
+// ContentType returns 415 unless the media type of the request is accept.  Parameters such as the charset are ignored,
+// e.g. "application/json; charset=utf-8" is accepted for "application/json".
 func ContentType(accept string, respond string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", respond)
 
 			// check content-type
-			if r.Header.Get("Content-Type") != accept {
+			if mediaType(r) != strings.ToLower(accept) {
 				// TODO: Use Error Response Helper/, whatever it becomes
 				w.WriteHeader(http.StatusUnsupportedMediaType)
 				json.NewEncoder(w).Encode(response.NewErrorResponse(http.StatusUnsupportedMediaType, http.StatusText(http.StatusUnsupportedMediaType)+". Expecting "+accept+" as Content-Type."))
@@ -23,6 +28,31 @@ func ContentType(accept string, respond string) func(next http.Handler) http.Han
 	}
 }
 
+// JsonContentType requires an application/json request body.  A PATCH can also be a JSON Merge Patch or a JSON Patch.
 func JsonContentType(next http.Handler) http.Handler {
-	return ContentType("application/json", "application/json")(next)
+	jsonOnly := ContentType("application/json", "application/json")(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			switch mediaType(r) {
+			case validation.MERGE_PATCH_CONTENT_TYPE, validation.JSON_PATCH_CONTENT_TYPE:
+				w.Header().Set("Content-Type", "application/json")
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		jsonOnly.ServeHTTP(w, r)
+	})
+}
+
+// mediaType returns the lower case media type of the Content-Type of the request without its parameters, or an empty
+// string when the header is missing or invalid
+func mediaType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	return mediaType
 }
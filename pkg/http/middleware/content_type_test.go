@@ -94,3 +94,40 @@ func TestJsonContentTypePasses(t *testing.T) {
 		t.Fatal("Content-Type is invalid" + w.Body.String())
 	}
 }
+
+func TestJsonContentTypePatch(t *testing.T) {
+	tests := []struct {
+		method string
+		cType  string
+		code   int
+	}{
+		{"PATCH", "application/merge-patch+json", http.StatusOK},
+		{"PATCH", "application/json-patch+json", http.StatusOK},
+		{"PATCH", "application/json", http.StatusOK},
+		{"PATCH", "application/merge-patch+json; charset=utf-8", http.StatusOK},
+		{"POST", "application/json; charset=utf-8", http.StatusOK},
+		{"POST", "Application/JSON", http.StatusOK},
+		{"POST", "application/json-seq", http.StatusUnsupportedMediaType},
+		{"POST", "application/merge-patch+json", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.cType, func(t *testing.T) {
+			okHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte("ok"))
+			})
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set("Content-Type", tt.cType)
+			w := httptest.NewRecorder()
+			JsonContentType(okHandler).ServeHTTP(w, r)
+
+			if w.Code != tt.code {
+				t.Errorf("Expected HTTP Status Code %d got %d", tt.code, w.Code)
+			}
+
+			if w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Expected application/json Content-Type, got %s", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+// TimezoneHeader holds the IANA name of the timezone that the response datetimes are rendered in, e.g. America/Chicago
+const TimezoneHeader = "X-Timezone"
+
+// Timezone adds the location of the X-Timezone header to the request context so the datetimes of the models are
+// rendered in it.  Datetimes are still stored in UTC.  An unknown timezone returns 400.
+func Timezone(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(TimezoneHeader)
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.NewErrorResponse(http.StatusBadRequest, http.StatusText(http.StatusBadRequest)+". HTTP Header "+TimezoneHeader+" is not a valid timezone."))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(types.ContextWithLocation(r.Context(), loc)))
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+func TestTimezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		code     int
+		location string
+	}{
+		{"Pass through without a header", "", http.StatusOK, ""},
+		{"Add the location to the context", "America/New_York", http.StatusOK, "America/New_York"},
+		{"Return a 400 for an unknown timezone", "Not/A_Timezone", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := ""
+			handler := Timezone(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if loc, ok := types.LocationFromContext(r.Context()); ok {
+					location = loc.String()
+				}
+			}))
+
+			r := httptest.NewRequest("GET", "/models/1", nil)
+			if tt.timezone != "" {
+				r.Header.Set(TimezoneHeader, tt.timezone)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.code {
+				t.Errorf("Expected HTTP Status Code %d got %d", tt.code, w.Code)
+			}
+
+			if location != tt.location {
+				t.Errorf("Expected location %s, got %s", tt.location, location)
+			}
+		})
+	}
+}
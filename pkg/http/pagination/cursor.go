@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+)
+
+const (
+	// CURSOR_PARAM opts into cursor pagination.  It can be empty to request the first page or contain a cursor which
+	// behaves the same as AFTER_PARAM.
+	CURSOR_PARAM = "cursor"
+	AFTER_PARAM  = "after"
+	BEFORE_PARAM = "before"
+)
+
+// IsCursor returns true when the request has opted into cursor pagination instead of page/size
+func (p Pagination) IsCursor() bool {
+	query := p.r.URL.Query()
+	for _, param := range []string{CURSOR_PARAM, AFTER_PARAM, BEFORE_PARAM} {
+		if _, ok := query[param]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Cursor decodes the cursor from the request.  A cursor with no values is returned when the first page is requested.
+func (p Pagination) Cursor() (db.Cursor, error) {
+	query := p.r.URL.Query()
+
+	if token := query.Get(BEFORE_PARAM); token != "" {
+		values, err := DecodeCursor(token)
+		return db.Cursor{Values: values, Before: true}, err
+	}
+
+	token := query.Get(AFTER_PARAM)
+	if token == "" {
+		token = query.Get(CURSOR_PARAM)
+	}
+
+	if token == "" {
+		return db.Cursor{}, nil
+	}
+
+	values, err := DecodeCursor(token)
+	return db.Cursor{Values: values}, err
+}
+
+// EncodeCursor creates an opaque token from the sort values of a row
+func EncodeCursor(values []interface{}) (string, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor returns the sort values from a token created by EncodeCursor
+func DecodeCursor(token string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("cursor is not valid")
+	}
+
+	// numbers are kept as strings so large integers don't lose precision
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var values []interface{}
+	if err := decoder.Decode(&values); err != nil || len(values) == 0 {
+		return nil, errors.New("cursor is not valid")
+	}
+
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package pagination
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeCursor(t *testing.T) {
+	token, err := EncodeCursor([]interface{}{"name", 12})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+
+	values, err := DecodeCursor(token)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+
+	expected := []interface{}{"name", json.Number("12")}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got %v", expected, values)
+	}
+
+	for _, invalid := range []string{"not-base64!", "bm90LWpzb24", "W10"} {
+		if _, err := DecodeCursor(invalid); err == nil {
+			t.Errorf("Expected error for %s, got none", invalid)
+		}
+	}
+}
+
+func TestPagination_Cursor(t *testing.T) {
+	token, _ := EncodeCursor([]interface{}{"a"})
+
+	tests := []struct {
+		uri      string
+		isCursor bool
+		values   []interface{}
+		before   bool
+	}{
+		{"/?page=2", false, nil, false},
+		{"/?cursor=", true, nil, false},
+		{"/?cursor=" + token, true, []interface{}{"a"}, false},
+		{"/?after=" + token, true, []interface{}{"a"}, false},
+		{"/?before=" + token, true, []interface{}{"a"}, true},
+	}
+
+	for _, test := range tests {
+		p := NewPagination(httptest.NewRequest("GET", test.uri, nil))
+		if p.IsCursor() != test.isCursor {
+			t.Errorf("%s: Expected IsCursor to be %v", test.uri, test.isCursor)
+		}
+
+		cursor, err := p.Cursor()
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %s", test.uri, err.Error())
+		}
+
+		if !reflect.DeepEqual(cursor.Values, test.values) || cursor.Before != test.before {
+			t.Errorf("%s: Unexpected cursor %v", test.uri, cursor)
+		}
+	}
+
+	p := NewPagination(httptest.NewRequest("GET", "/?after=invalid", nil))
+	if _, err := p.Cursor(); err == nil {
+		t.Error("Expected error for an invalid cursor")
+	}
+}
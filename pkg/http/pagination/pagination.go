@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 const defaultPageSize = 20
@@ -12,6 +14,43 @@ const maximumPageSize = 100
 const minimumPageSize = 10
 const defaultPage = 1
 
+const (
+	PAGE_PARAM = "page"
+	SIZE_PARAM = "size"
+)
+
+// PageQuery returns the raw query with the paging parameters (page, cursor, after and before) replaced by the param and
+// its value, or removed when param is empty.  The other parameters are kept as they are, in the same order and with the
+// same encoding, so that links to other pages keep e.g. sort[name]=desc exactly as the client sent it.
+func PageQuery(rawQuery string, param string, value string) string {
+	pair := url.QueryEscape(param) + "=" + url.QueryEscape(value)
+
+	parts := make([]string, 0)
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+
+		key, _ := url.QueryUnescape(strings.SplitN(part, "=", 2)[0])
+		switch key {
+		case PAGE_PARAM, CURSOR_PARAM, AFTER_PARAM, BEFORE_PARAM:
+			// the new paging parameter takes the place of the first one of the request
+			if param != "" {
+				parts = append(parts, pair)
+				param = ""
+			}
+		default:
+			parts = append(parts, part)
+		}
+	}
+
+	if param != "" {
+		parts = append(parts, pair)
+	}
+
+	return strings.Join(parts, "&")
+}
+
 type Pagination struct {
 	r *http.Request
 }
@@ -22,8 +61,8 @@ func NewPagination(r *http.Request) *Pagination {
 
 func (p Pagination) Size() uint64 {
 	var size uint64 = defaultPageSize
-	if len(p.r.URL.Query()["size"]) > 0 {
-		size, _ = strconv.ParseUint(p.r.URL.Query()["size"][0], 10, 0)
+	if len(p.r.URL.Query()[SIZE_PARAM]) > 0 {
+		size, _ = strconv.ParseUint(p.r.URL.Query()[SIZE_PARAM][0], 10, 0)
 		if size > maximumPageSize {
 			size = maximumPageSize
 		}
@@ -37,8 +76,8 @@ func (p Pagination) Size() uint64 {
 
 func (p Pagination) Offset() uint64 {
 	var offset uint64 = 0
-	if len(p.r.URL.Query()["page"]) > 0 {
-		page, _ := strconv.ParseUint(p.r.URL.Query()["page"][0], 10, 0)
+	if len(p.r.URL.Query()[PAGE_PARAM]) > 0 {
+		page, _ := strconv.ParseUint(p.r.URL.Query()[PAGE_PARAM][0], 10, 0)
 		if page < defaultPage {
 			page = defaultPage
 		}
@@ -61,8 +100,8 @@ func (p Pagination) TotalPages(count int) int {
 func (p Pagination) CurrentPage(count int) (int, error) {
 	// get page from query parameter
 	queryPage := int64(p.FirstPage())
-	if len(p.r.URL.Query()["page"]) > 0 {
-		queryPage, _ = strconv.ParseInt(p.r.URL.Query()["page"][0], 10, 0)
+	if len(p.r.URL.Query()[PAGE_PARAM]) > 0 {
+		queryPage, _ = strconv.ParseInt(p.r.URL.Query()[PAGE_PARAM][0], 10, 0)
 	}
 
 	firstPage := p.FirstPage()
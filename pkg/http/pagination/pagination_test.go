@@ -217,3 +217,24 @@ func TestPagination_NextPage(t *testing.T) {
 		t.Errorf("Expected error, got %d and error message %q", nextPage, err)
 	}
 }
+
+func TestPageQuery(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		param    string
+		value    string
+		expected string
+	}{
+		{"sort[name]=desc&page=2&size=10", PAGE_PARAM, "3", "sort[name]=desc&page=3&size=10"},
+		{"sort%5Bname%5D=desc&size=10", PAGE_PARAM, "1", "sort%5Bname%5D=desc&size=10&page=1"},
+		{"cursor=abc&fields[school]=name&before=def", AFTER_PARAM, "x/y", "after=x%2Fy&fields[school]=name"},
+		{"page=2&filter[age][gt]=3", "", "", "filter[age][gt]=3"},
+		{"", PAGE_PARAM, "1", "page=1"},
+	}
+
+	for _, test := range tests {
+		if query := PageQuery(test.rawQuery, test.param, test.value); query != test.expected {
+			t.Errorf("%s: Expected %s, got %s", test.rawQuery, test.expected, query)
+		}
+	}
+}
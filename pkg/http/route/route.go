@@ -6,4 +6,8 @@ const (
 	POST_ROUTE   = "POST_ROUTE"
 	PATCH_ROUTE  = "PATCH_ROUTE"
 	DELETE_ROUTE = "DELETE_ROUTE"
+
+	BULK_POST_ROUTE   = "BULK_POST_ROUTE"
+	BULK_PATCH_ROUTE  = "BULK_PATCH_ROUTE"
+	BULK_DELETE_ROUTE = "BULK_DELETE_ROUTE"
 )
@@ -0,0 +1,411 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gocraft/dbr"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+var (
+	ErrBulkBody        = errors.New("request body must be a JSON array")
+	ErrBulkId          = errors.New("id is required")
+	ErrBulkInvalidId   = errors.New("id is not valid")
+	ErrBulkDuplicateId = errors.New("id is listed more than once")
+	ErrBulkIdExists    = errors.New("a model with the id already exists")
+	ErrBulkConflict    = errors.New("one of the models conflicts with an existing model, none of them were created")
+	ErrBulkDeleted     = errors.New("one of the models was deleted by another request, none of them were deleted")
+)
+
+// BulkPostHandler creates each valid model of a JSON array of models.  The response is a collection with an item for
+// each element of the array, in the same order, that has the status of the element: 201 with the model when it was
+// created, 400 or 422 with the error when it was not, including an id that isn't valid and every element of an id that
+// is listed more than once, and 409 when a model with the id already exists.  The valid models are created together
+// with db.BatchRepository.CreateManyContext, when any of them violates a constraint of the database the request
+// returns a 409 and none of them are created.
+func (res *Resource) BulkPostHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		elements, ok := res.readBulkBody(w, r)
+		if !ok {
+			return
+		}
+
+		items := make([]response.SingleResponse, len(elements))
+		decoded := make([]reflect.Value, len(elements))
+		ids := make([]string, len(elements))
+		counts := make(map[string]int, len(elements))
+		for i, element := range elements {
+			model := res.newModel()
+			if code, err := res.decodeNewModel(r, element, model); code == http.StatusInternalServerError {
+				WriteInternalServerErrorResponse(w)
+				return
+			} else if err != nil {
+				items[i] = res.newItemError(r, code, err)
+				continue
+			}
+
+			value := reflect.ValueOf(model).Elem()
+			if id := value.FieldByName("Id"); id.IsValid() && id.Kind() == reflect.String {
+				if !res.validBulkId(id.String()) {
+					items[i] = res.newItemError(r, http.StatusBadRequest, ErrBulkInvalidId)
+					continue
+				}
+
+				ids[i] = id.String()
+				counts[ids[i]]++
+			}
+
+			decoded[i] = value
+		}
+
+		// the ids are checked up front so a single element can't make all of them fail, deleted models still have theirs
+		existing, err := res.findBulkModels(r, ids, true)
+		if err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		models := reflect.MakeSlice(reflect.SliceOf(res.modelType), 0, len(elements))
+		created := make([]int, 0, len(elements))
+		for i, model := range decoded {
+			if !model.IsValid() {
+				continue
+			}
+
+			if _, ok := existing[ids[i]]; ok {
+				items[i] = res.newItemError(r, http.StatusConflict, ErrBulkIdExists)
+				continue
+			} else if counts[ids[i]] > 1 {
+				items[i] = res.newItemError(r, http.StatusBadRequest, ErrBulkDuplicateId)
+				continue
+			}
+
+			models = reflect.Append(models, model)
+			created = append(created, i)
+		}
+
+		if err := res.batchRepository().CreateManyContext(r.Context(), models.Interface()); err != nil {
+			if db.IsConstraintError(err) {
+				WriteConflictErrorResponse(w, ErrBulkConflict)
+				return
+			}
+
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		for j, i := range created {
+			sr, err := res.newItem(r, models.Index(j).Interface(), http.StatusCreated)
+			if err != nil {
+				WriteBadRequestErrorResponse(w, err)
+				return
+			}
+
+			items[i] = sr
+		}
+
+		res.writeBulk(w, r, items)
+	}
+}
+
+// BulkPatchHandler applies each element of a JSON array of partial models to the model identified by the `id` of the
+// element.  The item of each element has a 200 with the model when it was saved, a 404 when the model doesn't exist or
+// the id isn't valid, as for PatchHandler, and a 400 or 422 with the error when it could not be applied, including
+// every element of an id that is listed more than once.  The valid models are saved together with
+// db.BatchRepository.UpdateFieldsManyContext so each model only changes the columns of its element, a conflict with
+// any of them returns a 409 and none of them are saved.
+func (res *Resource) BulkPatchHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		elements, ok := res.readBulkBody(w, r)
+		if !ok {
+			return
+		}
+
+		ids := make([]string, len(elements))
+		errs := make([]error, len(elements))
+		counts := make(map[string]int, len(elements))
+		for i, element := range elements {
+			var key struct {
+				Id string `json:"id"`
+			}
+
+			if err := json.Unmarshal(element, &key); err != nil {
+				errs[i] = err
+			} else if key.Id == "" {
+				errs[i] = ErrBulkId
+			}
+
+			ids[i] = key.Id
+			counts[key.Id]++
+		}
+
+		// it isn't clear which of the elements of an id should be applied so none of them are
+		for i, id := range ids {
+			if errs[i] == nil && counts[id] > 1 {
+				errs[i] = ErrBulkDuplicateId
+			}
+		}
+
+		found, err := res.findBulkModels(r, ids, false)
+		if err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		// the id of the element is allowed because it is the id the model was found by
+		validFields := append(res.updateFields(), "Id")
+
+		items := make([]response.SingleResponse, len(elements))
+		models := reflect.MakeSlice(reflect.SliceOf(res.modelType), 0, len(elements))
+		fields := make([][]string, 0, len(elements))
+		updated := make([]int, 0, len(elements))
+		for i, element := range elements {
+			if errs[i] != nil {
+				items[i] = res.newItemError(r, http.StatusBadRequest, errs[i])
+				continue
+			}
+
+			model, ok := found[ids[i]]
+			if !ok {
+				items[i] = res.newItemError(r, http.StatusNotFound, NotFound404)
+				continue
+			}
+
+			modelPtr := reflect.New(res.modelType)
+			modelPtr.Elem().Set(model)
+			patch, err := validation.DecodePatch(element, validFields, modelPtr.Interface())
+			if err != nil {
+				items[i] = res.newItemError(r, http.StatusBadRequest, err)
+				continue
+			}
+
+			if code, err := res.validate(r, modelPtr.Interface()); code == http.StatusInternalServerError {
+				WriteInternalServerErrorResponse(w)
+				return
+			} else if err != nil {
+				items[i] = res.newItemError(r, code, err)
+				continue
+			}
+
+			models = reflect.Append(models, modelPtr.Elem())
+			fields = append(fields, withoutId(patch.Fields()))
+			updated = append(updated, i)
+		}
+
+		if err := res.batchRepository().UpdateFieldsManyContext(r.Context(), models.Interface(), fields); err != nil {
+			var conflict *db.ConflictError
+			if errors.As(err, &conflict) {
+				WriteConflictErrorResponse(w, err)
+				return
+			}
+
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		for j, i := range updated {
+			sr, err := res.newItem(r, models.Index(j).Interface(), http.StatusOK)
+			if err != nil {
+				WriteBadRequestErrorResponse(w, err)
+				return
+			}
+
+			items[i] = sr
+		}
+
+		res.writeBulk(w, r, items)
+	}
+}
+
+// BulkDeleteHandler removes the models identified by a JSON array of ids.  The item of each id has a 204 when the model
+// was removed, a 404 when it doesn't exist or the id isn't valid and a 400 when the id isn't a string.  The models are
+// removed together with db.BatchRepository.DeleteManyContext, a conflict with any of them or a model that was deleted
+// in the meantime returns a 409 and none of them are removed.
+func (res *Resource) BulkDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		elements, ok := res.readBulkBody(w, r)
+		if !ok {
+			return
+		}
+
+		ids := make([]string, len(elements))
+		errs := make([]error, len(elements))
+		for i, element := range elements {
+			if err := json.Unmarshal(element, &ids[i]); err != nil {
+				errs[i] = err
+			} else if ids[i] == "" {
+				errs[i] = ErrBulkId
+			}
+		}
+
+		found, err := res.findBulkModels(r, ids, false)
+		if err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		items := make([]response.SingleResponse, len(elements))
+		models := reflect.MakeSlice(reflect.SliceOf(res.modelType), 0, len(elements))
+		for i := range elements {
+			if errs[i] != nil {
+				items[i] = res.newItemError(r, http.StatusBadRequest, errs[i])
+				continue
+			}
+
+			model, ok := found[ids[i]]
+			if !ok {
+				items[i] = res.newItemError(r, http.StatusNotFound, NotFound404)
+				continue
+			}
+
+			// an id that is listed twice is only removed once
+			delete(found, ids[i])
+			models = reflect.Append(models, model)
+
+			items[i], _ = response.CreateSingleResponse(nil, res.ResourceType, res.router, r)
+			items[i].SetStatus(http.StatusNoContent)
+		}
+
+		if err := res.batchRepository().DeleteManyContext(r.Context(), models.Interface()); err != nil {
+			var conflict *db.ConflictError
+			if errors.As(err, &conflict) {
+				WriteConflictErrorResponse(w, err)
+			} else if errors.Is(err, dbr.ErrNotFound) {
+				WriteConflictErrorResponse(w, ErrBulkDeleted)
+			} else {
+				WriteInternalServerErrorResponse(w)
+			}
+
+			return
+		}
+
+		res.writeBulk(w, r, items)
+	}
+}
+
+// withoutId removes the id from the fields of a bulk patch, it is only there to identify the model
+func withoutId(fields []string) []string {
+	without := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != "Id" {
+			without = append(without, f)
+		}
+	}
+
+	return without
+}
+
+// batchRepository returns the repository of the resource as a db.BatchRepository, readBulkBody already checked that
+// it is one
+func (res *Resource) batchRepository() db.BatchRepository {
+	return res.Repository.(db.BatchRepository)
+}
+
+// readBulkBody reads the JSON array of a bulk request.  The error response is written when the body can't be read or
+// the repository isn't a db.BatchRepository.
+func (res *Resource) readBulkBody(w http.ResponseWriter, r *http.Request) ([]json.RawMessage, bool) {
+	if _, ok := res.Repository.(db.BatchRepository); !ok {
+		WriteInternalServerErrorResponse(w)
+		return nil, false
+	}
+
+	body, err := validation.ReadJSON(res.body(r))
+	if errors.Is(err, validation.ErrBodyTooLarge) {
+		WritePayloadTooLargeErrorResponse(w, err)
+		return nil, false
+	} else if err != nil {
+		WriteBadRequestErrorResponse(w, ErrBulkBody)
+		return nil, false
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(body, &elements); err != nil {
+		WriteBadRequestErrorResponse(w, ErrBulkBody)
+		return nil, false
+	}
+
+	return elements, true
+}
+
+// findBulkModels loads the models of the ids with a single query, keyed by id.  Soft deleted models are only loaded
+// when withDeleted is true.  Ids that aren't valid for the model, see validBulkId, are never found so that a single
+// malformed id can't fail the query of all of them.
+func (res *Resource) findBulkModels(r *http.Request, ids []string, withDeleted bool) (map[string]reflect.Value, error) {
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && res.validBulkId(id) {
+			values = append(values, id)
+		}
+	}
+
+	found := make(map[string]reflect.Value, len(values))
+	if len(values) == 0 {
+		return found, nil
+	}
+
+	fb := db.FindBy{
+		Filters:     []db.Filter{{Field: db.PRIMARY_KEY, Operator: db.OPERATOR_IN, Value: values}},
+		WithDeleted: withDeleted,
+	}
+
+	models := reflect.New(reflect.SliceOf(res.modelType))
+	if err := db.FindByContext(r.Context(), res.Repository, models.Interface(), fb); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < models.Elem().Len(); i++ {
+		model := models.Elem().Index(i)
+		found[model.FieldByName("Id").String()] = model
+	}
+
+	return found, nil
+}
+
+// validBulkId checks the id the same way as the filters of GetQueryParams, it must be a UUID v4 when the validate tag
+// of the id requires a UUID
+func (res *Resource) validBulkId(id string) bool {
+	return !isFieldUUID(res.Model, "id") || ValidateId(id, res.Validator) == nil
+}
+
+// newItem creates the item of a bulk response for a model that was saved
+func (res *Resource) newItem(r *http.Request, model interface{}, code int) (response.SingleResponse, error) {
+	sr, err := response.NewModelSingleResponse(model, res.RouteNames, res.ResourceType, res.router, r)
+	sr.SetStatus(code)
+
+	return sr, err
+}
+
+// newItemError creates the item of a bulk response for a model that could not be saved.  The messages of the invalid
+// fields are in the language of the Accept-Language header.
+func (res *Resource) newItemError(r *http.Request, code int, err error) response.SingleResponse {
+	trans := validation.GetValidatorTranslator(res.Validator, r.Header.Get("Accept-Language"))
+	sr, _ := response.NewItemErrorResponse(code, err.Error(), getFieldErrors(err, trans), res.ResourceType, res.router, r)
+
+	return sr
+}
+
+// writeBulk writes the items of a bulk request as a collection with a 207 Multi-Status
+func (res *Resource) writeBulk(w http.ResponseWriter, r *http.Request, items []response.SingleResponse) {
+	cm := response.CollectionMetadata{
+		Count:  len(items),
+		Sort:   []response.CollectionSort{},
+		Filter: map[string]interface{}{},
+	}
+
+	cr, err := response.CreateCollectionResponse(cm, res.ResourceType, res.router, r)
+	if err != nil {
+		WriteBadRequestErrorResponse(w, err)
+		return
+	}
+
+	for _, item := range items {
+		cr.AddItem(item)
+	}
+
+	WriteCollectionResponse(cr, w, http.StatusMultiStatus)
+}
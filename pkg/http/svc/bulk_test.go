@@ -0,0 +1,327 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/dbr"
+	"github.com/gorilla/mux"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+const otherResourceId = "5d0c1a8e-3d5b-4f4b-9e7e-0b9e6d0a2f4c"
+
+// bulkBody is the collection written by the bulk handlers
+type bulkBody struct {
+	Items []struct {
+		Model  map[string]interface{} `json:"model"`
+		Status int                    `json:"status"`
+		Error  struct {
+			Code    int                   `json:"code"`
+			Message string                `json:"message"`
+			Errors  []response.FieldError `json:"errors"`
+		} `json:"error"`
+	} `json:"items"`
+	Metadata struct {
+		Count int `json:"count"`
+	} `json:"metadata"`
+}
+
+// conflictRepo returns a conflict for every bulk update
+type conflictRepo struct {
+	*memoryRepo
+}
+
+func (r *conflictRepo) UpdateFieldsManyContext(ctx context.Context, objects interface{}, fields [][]string) error {
+	return &db.ConflictError{Table: "model", Id: resourceId}
+}
+
+// constraintRepo returns a unique violation for every bulk create
+type constraintRepo struct {
+	*memoryRepo
+}
+
+func (r *constraintRepo) CreateManyContext(ctx context.Context, objects interface{}) error {
+	return constraintError{}
+}
+
+// constraintError is a unique violation of Postgres
+type constraintError struct{}
+
+func (e constraintError) Error() string {
+	return "duplicate key value violates unique constraint"
+}
+
+func (e constraintError) SQLState() string {
+	return "23505"
+}
+
+// newBulkTestResource attaches the routes of a Resource with the bulk routes
+func newBulkTestResource(repo db.Repository) *mux.Router {
+	router := mux.NewRouter()
+	res := NewResource(Model{}, repo, validation.Singleton(), "model", resourceRouteNames)
+	res.Bulk = true
+	res.AttachRoutes(router, "/models")
+
+	return router
+}
+
+func serveBulk(t *testing.T, repo *memoryRepo, method string, body string) (*httptest.ResponseRecorder, bulkBody) {
+	router := newBulkTestResource(repo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, "/models/bulk", strings.NewReader(body)))
+
+	var bb bulkBody
+	if w.Code == http.StatusMultiStatus {
+		if err := json.Unmarshal(w.Body.Bytes(), &bb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return w, bb
+}
+
+func TestResource_BulkPostHandler(t *testing.T) {
+	t.Run("Return the status of each model", func(t *testing.T) {
+		repo := newMemoryRepo()
+		w, body := serveBulk(t, repo, "POST", `[{"name":"first"},{"name":"a"},{"invalid":"test"},{"name":"second"}]`)
+
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+		}
+
+		expected := []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusBadRequest, http.StatusCreated}
+		if len(body.Items) != len(expected) || body.Metadata.Count != len(expected) {
+			t.Fatalf("Expected %d items, got %s", len(expected), w.Body.String())
+		}
+
+		for i, status := range expected {
+			if body.Items[i].Status != status {
+				t.Errorf("Expected item %d to have status %d, got %d", i, status, body.Items[i].Status)
+			}
+		}
+
+		if body.Items[0].Model["name"] != "first" || body.Items[3].Model["name"] != "second" {
+			t.Errorf("Expected the created models in the order of the request, got %s", w.Body.String())
+		}
+
+		if errs := body.Items[1].Error.Errors; len(errs) != 1 || errs[0].Field != "name" || errs[0].Rule != "min" {
+			t.Errorf("Expected the invalid field, got %s", w.Body.String())
+		}
+
+		if len(repo.models) != 2 {
+			t.Errorf("Expected 2 models to be created, got %d", len(repo.models))
+		}
+	})
+
+	t.Run("Return a 400 when the body is not an array", func(t *testing.T) {
+		w, _ := serveBulk(t, newMemoryRepo(), "POST", `{"name":"test"}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Reject the ids that exist or are listed more than once", func(t *testing.T) {
+		repo := newMemoryRepo(Model{Id: resourceId})
+		w, body := serveBulk(t, repo, "POST", `[
+			{"id":"`+resourceId+`","name":"exists"},
+			{"id":"`+otherResourceId+`","name":"first"},
+			{"id":"`+otherResourceId+`","name":"second"},
+			{"name":"new"}
+		]`)
+
+		expected := []int{http.StatusConflict, http.StatusBadRequest, http.StatusBadRequest, http.StatusCreated}
+		for i, status := range expected {
+			if i >= len(body.Items) || body.Items[i].Status != status {
+				t.Fatalf("Expected item %d to have status %d, got %s", i, status, w.Body.String())
+			}
+		}
+
+		if body.Items[0].Error.Message != ErrBulkIdExists.Error() || body.Items[1].Error.Message != ErrBulkDuplicateId.Error() {
+			t.Errorf("Expected the errors of the ids, got %s", w.Body.String())
+		}
+
+		if _, ok := repo.models[otherResourceId]; ok || len(repo.models) != 2 {
+			t.Errorf("Expected only the new model to be created, got %v", repo.models)
+		}
+	})
+
+	t.Run("Reject an id that is not valid without querying it", func(t *testing.T) {
+		repo := newMemoryRepo()
+		w, body := serveBulk(t, repo, "POST", `[{"id":"not-a-uuid","name":"invalid"},{"id":"`+otherResourceId+`","name":"valid"}]`)
+
+		expected := []int{http.StatusUnprocessableEntity, http.StatusCreated}
+		for i, status := range expected {
+			if i >= len(body.Items) || body.Items[i].Status != status {
+				t.Fatalf("Expected item %d to have status %d, got %s", i, status, w.Body.String())
+			}
+		}
+
+		if ids := repo.findBy.Filters[0].Value; !reflect.DeepEqual(ids, []interface{}{otherResourceId}) {
+			t.Errorf("Expected only the valid id to be queried, got %v", ids)
+		}
+	})
+
+	t.Run("Return a 409 when a model violates a constraint", func(t *testing.T) {
+		router := newBulkTestResource(&constraintRepo{memoryRepo: newMemoryRepo()})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models/bulk", strings.NewReader(`[{"name":"test"}]`)))
+
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), ErrBulkConflict.Error()) {
+			t.Errorf("Expected status code %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Return a 500 when the models can't be created", func(t *testing.T) {
+		repo := newMemoryRepo()
+		repo.err = errors.New("insert failed")
+		w, _ := serveBulk(t, repo, "POST", `[{"name":"test"}]`)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestResource_BulkPatchHandler(t *testing.T) {
+	newRepo := func() *memoryRepo {
+		return newMemoryRepo(
+			Model{Id: resourceId, Name: types.NewNullString("test", true)},
+			Model{Id: otherResourceId, Name: types.NewNullString("other", true)},
+		)
+	}
+
+	t.Run("Return the status of each model", func(t *testing.T) {
+		repo := newRepo()
+		w, body := serveBulk(t, repo, "PATCH", `[
+			{"id":"`+resourceId+`","name":"updated"},
+			{"id":"`+otherResourceId+`","name":"a"},
+			{"id":"c24b2909-92e3-4266-ac13-000000000000","name":"missing"},
+			{"name":"no id"}
+		]`)
+
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+		}
+
+		expected := []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusNotFound, http.StatusBadRequest}
+		for i, status := range expected {
+			if i >= len(body.Items) || body.Items[i].Status != status {
+				t.Fatalf("Expected item %d to have status %d, got %s", i, status, w.Body.String())
+			}
+		}
+
+		if repo.models[resourceId].Name.String.String != "updated" || repo.models[otherResourceId].Name.String.String != "other" {
+			t.Errorf("Expected only the valid model to be saved, got %v", repo.models)
+		}
+
+		if !reflect.DeepEqual(repo.bulkFields, [][]string{{"Name"}}) {
+			t.Errorf("Expected only the name of the valid model to be saved, got %v", repo.bulkFields)
+		}
+	})
+
+	t.Run("Reject every element of an id that is listed more than once", func(t *testing.T) {
+		repo := newRepo()
+		w, body := serveBulk(t, repo, "PATCH", `[
+			{"id":"`+resourceId+`","name":"first"},
+			{"id":"`+otherResourceId+`","showProduct":true},
+			{"id":"`+resourceId+`","name":"second"}
+		]`)
+
+		expected := []int{http.StatusBadRequest, http.StatusOK, http.StatusBadRequest}
+		for i, status := range expected {
+			if i >= len(body.Items) || body.Items[i].Status != status {
+				t.Fatalf("Expected item %d to have status %d, got %s", i, status, w.Body.String())
+			}
+		}
+
+		if body.Items[0].Error.Message != ErrBulkDuplicateId.Error() || repo.models[resourceId].Name.String.String != "test" {
+			t.Errorf("Expected the duplicate id to be rejected, got %s", w.Body.String())
+		}
+
+		if !reflect.DeepEqual(repo.bulkFields, [][]string{{"ShowProduct"}}) {
+			t.Errorf("Expected only showProduct to be saved, got %v", repo.bulkFields)
+		}
+	})
+
+	t.Run("Return a 404 for an id that is not valid without querying it", func(t *testing.T) {
+		repo := newRepo()
+		w, body := serveBulk(t, repo, "PATCH", `[{"id":"not-a-uuid","name":"invalid"},{"id":"`+resourceId+`","name":"updated"}]`)
+
+		expected := []int{http.StatusNotFound, http.StatusOK}
+		for i, status := range expected {
+			if i >= len(body.Items) || body.Items[i].Status != status {
+				t.Fatalf("Expected item %d to have status %d, got %s", i, status, w.Body.String())
+			}
+		}
+
+		if ids := repo.findBy.Filters[0].Value; !reflect.DeepEqual(ids, []interface{}{resourceId}) {
+			t.Errorf("Expected only the valid id to be queried, got %v", ids)
+		}
+	})
+
+	t.Run("Return a 409 when a model was modified", func(t *testing.T) {
+		router := newBulkTestResource(&conflictRepo{memoryRepo: newRepo()})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PATCH", "/models/bulk", strings.NewReader(`[{"id":"`+resourceId+`","name":"updated"}]`)))
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status code %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+}
+
+// deletedRepo returns dbr.ErrNotFound for every bulk delete, as if another request deleted one of the models
+type deletedRepo struct {
+	*memoryRepo
+}
+
+func (r *deletedRepo) DeleteManyContext(ctx context.Context, objects interface{}) error {
+	return dbr.ErrNotFound
+}
+
+func TestResource_BulkDeleteHandler(t *testing.T) {
+	t.Run("Return the status of each id", func(t *testing.T) {
+		repo := newMemoryRepo(Model{Id: resourceId}, Model{Id: otherResourceId})
+		w, body := serveBulk(t, repo, "DELETE", `["`+resourceId+`","c24b2909-92e3-4266-ac13-000000000000",1,"not-a-uuid"]`)
+
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+		}
+
+		if ids := repo.findBy.Filters[0].Value; !reflect.DeepEqual(ids, []interface{}{resourceId, "c24b2909-92e3-4266-ac13-000000000000"}) {
+			t.Errorf("Expected only the valid ids to be queried, got %v", ids)
+		}
+
+		expected := []int{http.StatusNoContent, http.StatusNotFound, http.StatusBadRequest, http.StatusNotFound}
+		for i, status := range expected {
+			if i >= len(body.Items) || body.Items[i].Status != status {
+				t.Fatalf("Expected item %d to have status %d, got %s", i, status, w.Body.String())
+			}
+		}
+
+		if _, ok := repo.models[resourceId]; ok || len(repo.models) != 1 {
+			t.Errorf("Expected only %s to be deleted, got %v", resourceId, repo.models)
+		}
+	})
+
+	t.Run("Return a 409 when a model was deleted by another request", func(t *testing.T) {
+		router := newBulkTestResource(&deletedRepo{memoryRepo: newMemoryRepo(Model{Id: resourceId})})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/models/bulk", strings.NewReader(`["`+resourceId+`"]`)))
+
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), ErrBulkDeleted.Error()) {
+			t.Errorf("Expected status code %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package svc
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+)
+
+// FIELDS_PARAM limits the properties of the response, `fields=id,name` for the resource and `fields[relation]=id,name`
+// for an embedded relation
+const FIELDS_PARAM = "fields"
+
+// GetFieldsets returns the properties listed by the fields parameters of the query.  The fields of the resource are
+// keyed by an empty string and the fields of an embedded relation by the name of the relation.
+func GetFieldsets(query url.Values) map[string][]string {
+	fieldsets := make(map[string][]string)
+
+	for key, values := range query {
+		name := ""
+		if key != FIELDS_PARAM {
+			if !strings.HasPrefix(key, FIELDS_PARAM+"[") || !strings.HasSuffix(key, "]") {
+				continue
+			}
+
+			name = key[len(FIELDS_PARAM)+1 : len(key)-1]
+		}
+
+		for _, value := range values {
+			for _, field := range strings.Split(value, ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					fieldsets[name] = append(fieldsets[name], field)
+				}
+			}
+		}
+	}
+
+	return fieldsets
+}
+
+// ValidateFields returns an error for the first field that isn't a property of the model
+func ValidateFields(fields []string, model interface{}) error {
+	properties := db.GetJsonToDbMap(model)
+	for _, field := range fields {
+		if _, ok := properties[field]; !ok {
+			return errors.New(field + ": This property does not exist.")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package svc
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestGetFieldsets(t *testing.T) {
+	query, _ := url.ParseQuery("fields=id,%20name,&fields[enrollments]=id&sort[name]=asc&fieldset=id")
+
+	expected := map[string][]string{
+		"":            {"id", "name"},
+		"enrollments": {"id"},
+	}
+
+	if fieldsets := GetFieldsets(query); !reflect.DeepEqual(fieldsets, expected) {
+		t.Errorf("Expected %v, got %v", expected, fieldsets)
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	if err := ValidateFields([]string{"id", "name"}, Model{}); err != nil {
+		t.Errorf("Did not expect error and got: %s", err)
+	}
+
+	if err := ValidateFields([]string{"id", "invalid"}, Model{}); err == nil || err.Error() != "invalid: This property does not exist." {
+		t.Errorf("Expected the property to not exist, got %v", err)
+	}
+}
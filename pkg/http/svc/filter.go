@@ -0,0 +1,193 @@
+package svc
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+const FILTER_VALUE_DELIMITER = ","
+
+var (
+	datetimeType     = reflect.TypeOf(types.Datetime{})
+	nullDatetimeType = reflect.TypeOf(types.NullDatetime{})
+	dateType         = reflect.TypeOf(types.Date{})
+	nullDateType     = reflect.TypeOf(types.NullDate{})
+	nullStringType   = reflect.TypeOf(types.NullString{})
+	nullIntType      = reflect.TypeOf(types.NullInt{})
+	nullFloatType    = reflect.TypeOf(types.NullFloat{})
+	nullBoolType     = reflect.TypeOf(types.NullBool{})
+	nullUUIDType     = reflect.TypeOf(types.NullUUID{})
+	nullJSONType     = reflect.TypeOf(types.NullJSON{})
+)
+
+// nullValueTypes are the types that the null types of pkg/types store their value as
+var nullValueTypes = map[reflect.Type]reflect.Type{
+	nullStringType: reflect.TypeOf(""),
+	nullIntType:    reflect.TypeOf(int64(0)),
+	nullFloatType:  reflect.TypeOf(float64(0)),
+	nullBoolType:   reflect.TypeOf(false),
+	nullUUIDType:   reflect.TypeOf(""),
+}
+
+// nullableTypes are the types of pkg/types that can hold a null value
+var nullableTypes = map[reflect.Type]bool{
+	nullDatetimeType: true,
+	nullDateType:     true,
+	nullStringType:   true,
+	nullIntType:      true,
+	nullFloatType:    true,
+	nullBoolType:     true,
+	nullUUIDType:     true,
+	nullJSONType:     true,
+}
+
+// filterDatetimeFormats are the formats accepted when filtering by a datetime, in order of preference
+var filterDatetimeFormats = []string{
+	time.RFC3339,
+	types.FORMAT_DATETIME_INPUT,
+	types.FORMAT_DATE,
+}
+
+// parseFilter converts a `filter[field][operator]=value` query parameter into a db.Filter.  The value is converted to
+// the type of the model's struct member so it can be compared by the database.
+func parseFilter(model interface{}, field string, operator string, value string, validator *validation.Validator) (db.Filter, error) {
+	if operator == "" {
+		operator = db.OPERATOR_EQ
+	}
+
+	if !db.IsOperator(operator) {
+		return db.Filter{}, errors.New("filter: '" + operator + "' is not a valid operator for '" + field + "'.")
+	}
+
+	value, err := url.QueryUnescape(value)
+	if err != nil || value == "" {
+		return db.Filter{}, errors.New("filter: '" + field + "' field cannot be blank.")
+	}
+
+	fieldType, ok := getFieldType(model, field)
+	if !ok {
+		return db.Filter{}, errors.New(field + ": This property does not exist.")
+	}
+
+	valueType := fieldType
+	if t, ok := nullValueTypes[fieldType]; ok {
+		valueType = t
+	}
+
+	f := db.Filter{Field: field, Operator: operator}
+
+	switch operator {
+	case db.OPERATOR_NULL:
+		if !isNullableType(fieldType) {
+			return db.Filter{}, errors.New("filter: '" + field + "' field cannot be null.")
+		}
+
+		if value != "true" && value != "false" {
+			return db.Filter{}, errors.New("filter: '" + field + "' field must be 'true' or 'false' for 'null'.")
+		}
+
+		f.Value = value == "true"
+		return f, nil
+	case db.OPERATOR_GT, db.OPERATOR_GTE, db.OPERATOR_LT, db.OPERATOR_LTE, db.OPERATOR_BETWEEN:
+		if valueType.Kind() == reflect.Bool {
+			return db.Filter{}, errors.New("filter: '" + field + "' field cannot be compared with '" + operator + "'.")
+		}
+	}
+
+	values := []string{value}
+	if operator == db.OPERATOR_IN || operator == db.OPERATOR_BETWEEN {
+		values = strings.Split(value, FILTER_VALUE_DELIMITER)
+	}
+
+	if operator == db.OPERATOR_BETWEEN && len(values) != 2 {
+		return db.Filter{}, errors.New("filter: '" + field + "' field requires two values for 'between'.")
+	}
+
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		if isFieldUUID(model, field) && ValidateId(v, validator) != nil {
+			return db.Filter{}, errors.New(field + ": Invalid UUID v4.")
+		}
+
+		converted[i], err = convertFilterValue(v, valueType)
+		if err != nil {
+			return db.Filter{}, errors.New(field + ": Invalid value '" + v + "'.")
+		}
+	}
+
+	if operator == db.OPERATOR_IN || operator == db.OPERATOR_BETWEEN {
+		f.Value = converted
+	} else {
+		f.Value = converted[0]
+	}
+
+	return f, nil
+}
+
+// convertFilterValue converts the query string value into a value of the same underlying type as the struct member.
+// The null types other than the dates are passed as the type of their value, see nullValueTypes.
+func convertFilterValue(value string, t reflect.Type) (interface{}, error) {
+	switch t {
+	case datetimeType, nullDatetimeType:
+		return parseFilterTime(value, filterDatetimeFormats)
+	case dateType, nullDateType:
+		return parseFilterTime(value, []string{types.FORMAT_DATE})
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		if value != "true" && value != "false" {
+			return nil, errors.New("not a boolean")
+		}
+		return value == "true", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(value, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, t.Bits())
+	}
+
+	return nil, errors.New("type cannot be filtered")
+}
+
+// parseFilterTime parses the value with the first format that matches and returns it in UTC
+func parseFilterTime(value string, formats []string) (time.Time, error) {
+	var err error
+	for _, format := range formats {
+		var t time.Time
+		t, err = time.Parse(format, value)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// getFieldType returns the type of the struct member that has the json field name
+func getFieldType(model interface{}, field string) (reflect.Type, bool) {
+	t := reflect.TypeOf(model)
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == field {
+			return t.Field(i).Type, true
+		}
+	}
+
+	return nil, false
+}
+
+// isNullableType returns true if the type can hold a null value, a pointer or one of the null types of pkg/types
+func isNullableType(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr || nullableTypes[t]
+}
@@ -0,0 +1,154 @@
+package svc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+func TestValidFilter(t *testing.T) {
+	validator := validation.Singleton()
+	model := Model{}
+
+	tests := []struct {
+		uri      string
+		expected []db.Filter
+	}{
+		{
+			"?filter[name]=test",
+			[]db.Filter{{Field: "name", Operator: db.OPERATOR_EQ, Value: "test"}},
+		},
+		{
+			"?filter[name][in]=a,b",
+			[]db.Filter{{Field: "name", Operator: db.OPERATOR_IN, Value: []interface{}{"a", "b"}}},
+		},
+		{
+			"?filter[createdAt][gte]=2024-01-01",
+			[]db.Filter{{Field: "createdAt", Operator: db.OPERATOR_GTE, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"?filter[createdAt][between]=2024-01-01%2000:00:00,2024-02-01T00:00:00%2B01:00",
+			[]db.Filter{{Field: "createdAt", Operator: db.OPERATOR_BETWEEN, Value: []interface{}{
+				time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC),
+			}}},
+		},
+		{
+			"?filter[createdAt][null]=true&filter[showProduct]=false",
+			[]db.Filter{
+				{Field: "createdAt", Operator: db.OPERATOR_NULL, Value: true},
+				{Field: "showProduct", Operator: db.OPERATOR_EQ, Value: false},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		fb := &db.FindBy{}
+		err := GetQueryParams(test.uri, fb, model, validator)
+
+		if err != nil {
+			t.Errorf("%s: Expected nil, got %v", test.uri, err)
+		}
+
+		if !reflect.DeepEqual(fb.Filters, test.expected) {
+			t.Errorf("%s: Expected %v, got %v", test.uri, test.expected, fb.Filters)
+		}
+	}
+}
+
+// NullCode isn't one of the null types of pkg/types even though its name starts with Null
+type NullCode string
+
+type nullTypesModel struct {
+	Count    types.NullInt   `json:"count" db:"count"`
+	Score    types.NullFloat `json:"score" db:"score"`
+	Active   types.NullBool  `json:"active" db:"active"`
+	SchoolId types.NullUUID  `json:"schoolId" db:"school_id"`
+	Code     NullCode        `json:"code" db:"code"`
+}
+
+func TestFilterNullTypes(t *testing.T) {
+	validator := validation.Singleton()
+
+	tests := []struct {
+		uri      string
+		expected []db.Filter
+	}{
+		{
+			"?filter[count][gt]=1",
+			[]db.Filter{{Field: "count", Operator: db.OPERATOR_GT, Value: int64(1)}},
+		},
+		{
+			"?filter[score][between]=1.5,2",
+			[]db.Filter{{Field: "score", Operator: db.OPERATOR_BETWEEN, Value: []interface{}{1.5, float64(2)}}},
+		},
+		{
+			"?filter[active]=true",
+			[]db.Filter{{Field: "active", Operator: db.OPERATOR_EQ, Value: true}},
+		},
+		{
+			"?filter[schoolId]=" + resourceId,
+			[]db.Filter{{Field: "schoolId", Operator: db.OPERATOR_EQ, Value: resourceId}},
+		},
+		{
+			"?filter[count][null]=true",
+			[]db.Filter{{Field: "count", Operator: db.OPERATOR_NULL, Value: true}},
+		},
+	}
+
+	for _, test := range tests {
+		fb := &db.FindBy{}
+		if err := GetQueryParams(test.uri, fb, nullTypesModel{}, validator); err != nil {
+			t.Errorf("%s: Expected nil, got %v", test.uri, err)
+		}
+
+		if !reflect.DeepEqual(fb.Filters, test.expected) {
+			t.Errorf("%s: Expected %v, got %v", test.uri, test.expected, fb.Filters)
+		}
+	}
+
+	invalid := map[string]string{
+		"?filter[count][gt]=a":     "count: Invalid value 'a'.",
+		"?filter[active][gt]=true": "filter: 'active' field cannot be compared with 'gt'.",
+		"?filter[code][null]=true": "filter: 'code' field cannot be null.",
+	}
+
+	for uri, expected := range invalid {
+		if err := GetQueryParams(uri, &db.FindBy{}, nullTypesModel{}, validator); err == nil || err.Error() != expected {
+			t.Errorf("%s: Expected \"%s\", got %v", uri, expected, err)
+		}
+	}
+}
+
+func TestInvalidFilter(t *testing.T) {
+	validator := validation.Singleton()
+	model := Model{}
+
+	tests := map[string]string{
+		"?filter[name][like]=test":               "filter: 'like' is not a valid operator for 'name'.",
+		"?filter[name]=":                         "filter: 'name' field cannot be blank.",
+		"?filter[id]=test":                       "id: Invalid UUID v4.",
+		"?filter[createdAt][gt]=yesterday":       "createdAt: Invalid value 'yesterday'.",
+		"?filter[createdAt][between]=2024-01-01": "filter: 'createdAt' field requires two values for 'between'.",
+		"?filter[showProduct][gt]=true":          "filter: 'showProduct' field cannot be compared with 'gt'.",
+		"?filter[showProduct][null]=true":        "filter: 'showProduct' field cannot be null.",
+		"?filter[name][null]=yes":                "filter: 'name' field must be 'true' or 'false' for 'null'.",
+	}
+
+	for uri, expected := range tests {
+		err := GetQueryParams(uri, &db.FindBy{}, model, validator)
+
+		if err == nil {
+			t.Errorf("%s: Expected error, got nil", uri)
+			continue
+		}
+
+		if err.Error() != expected {
+			t.Errorf("%s: Expected \"%s\", got \"%s\"", uri, expected, err.Error())
+		}
+	}
+}
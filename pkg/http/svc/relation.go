@@ -0,0 +1,119 @@
+package svc
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+)
+
+// INCLUDE_PARAM embeds related models in the response, e.g. `include=enrollments,school`
+const INCLUDE_PARAM = "include"
+
+// Relation is a db.Relation that can be embedded in the responses of a Resource with the include parameter.  The
+// related models have their own links, which are generated from the resource type and route names of the relation.
+type Relation struct {
+	db.Relation
+	ResourceType string
+	RouteNames   map[string]string
+}
+
+// GetIncludes returns the names of the relations listed by the include parameter of the query
+func GetIncludes(query url.Values) []string {
+	includes := make([]string, 0)
+	for _, value := range query[INCLUDE_PARAM] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				includes = append(includes, name)
+			}
+		}
+	}
+
+	return includes
+}
+
+// getRelation returns the relation of the resource with the name
+func (res *Resource) getRelation(name string) (Relation, bool) {
+	for _, rel := range res.Relations {
+		if rel.Name == name {
+			return rel, true
+		}
+	}
+
+	return Relation{}, false
+}
+
+// getIncludes returns the relations listed by the include parameter.  A relation that is listed more than once is only
+// included once.
+func (res *Resource) getIncludes(r *http.Request) ([]Relation, error) {
+	includes := make([]Relation, 0)
+	included := make(map[string]bool)
+	for _, name := range GetIncludes(r.URL.Query()) {
+		rel, ok := res.getRelation(name)
+		if !ok {
+			return nil, errors.New(name + ": This relation does not exist.")
+		}
+
+		if !included[name] {
+			included[name] = true
+			includes = append(includes, rel)
+		}
+	}
+
+	return includes, nil
+}
+
+// embed loads the included relations of the models with a query per relation and embeds the related models in the
+// response of each model.  The responses are in the same order as the models.
+func (res *Resource) embed(r *http.Request, includes []Relation, models reflect.Value, items []response.SingleResponse) error {
+	fieldsets := GetFieldsets(r.URL.Query())
+
+	for _, rel := range includes {
+		loaded, err := rel.Load(r.Context(), models.Interface())
+		if err != nil {
+			return err
+		}
+
+		for i, related := range loaded {
+			if related == nil {
+				items[i].Embed(rel.Name, nil)
+				continue
+			}
+
+			if rel.Type == db.RELATION_BELONGS_TO {
+				sr, err := res.newRelatedResponse(r, rel, related, fieldsets[rel.Name])
+				if err != nil {
+					return err
+				}
+
+				items[i].Embed(rel.Name, sr)
+				continue
+			}
+
+			v := reflect.ValueOf(related)
+			srs := make([]response.SingleResponse, v.Len())
+			for j := range srs {
+				if srs[j], err = res.newRelatedResponse(r, rel, v.Index(j).Interface(), fieldsets[rel.Name]); err != nil {
+					return err
+				}
+			}
+
+			items[i].Embed(rel.Name, srs)
+		}
+	}
+
+	return nil
+}
+
+// newRelatedResponse creates the response of a related model, which is limited to the fields of the relation instead of
+// the fields of the resource
+func (res *Resource) newRelatedResponse(r *http.Request, rel Relation, model interface{}, fields []string) (response.SingleResponse, error) {
+	sr, err := response.NewModelSingleResponse(model, rel.RouteNames, rel.ResourceType, res.router, r)
+	sr.SetFields(fields)
+
+	return sr, err
+}
@@ -0,0 +1,239 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/route"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+type Enrollment struct {
+	Id      string `json:"id" db:"id" structs:"id"`
+	ModelId string `json:"modelId" db:"model_id" structs:"model_id"`
+}
+
+var enrollmentRouteNames = map[string]string{
+	route.GET_ROUTE: "get_enrollment",
+}
+
+// enrollmentRepo is an in memory db.Repository of enrollments that supports the filters of a relation
+type enrollmentRepo struct {
+	db.BaseRepository
+	enrollments []Enrollment
+	findBy      db.FindBy
+}
+
+func (r *enrollmentRepo) FindContext(ctx context.Context, object interface{}, id string) error {
+	for _, e := range r.enrollments {
+		if e.Id == id {
+			*object.(*Enrollment) = e
+			return nil
+		}
+	}
+
+	return NotFound404
+}
+
+func (r *enrollmentRepo) FindByContext(ctx context.Context, objects interface{}, fb db.FindBy) error {
+	r.findBy = fb
+
+	for _, e := range r.enrollments {
+		matches := true
+		for _, f := range fb.Filters {
+			key := e.Id
+			if f.Field == "modelId" {
+				key = e.ModelId
+			}
+
+			matches = matches && containsKey(f.Value.([]interface{}), key)
+		}
+
+		if matches {
+			*objects.(*[]Enrollment) = append(*objects.(*[]Enrollment), e)
+		}
+	}
+
+	return nil
+}
+
+func (r *enrollmentRepo) CountContext(ctx context.Context, object interface{}, fb db.FindBy) (int, error) {
+	return len(r.enrollments), nil
+}
+
+func containsKey(keys []interface{}, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// embeddedBody is an item with the embedded enrollments or model
+type embeddedBody struct {
+	Model    map[string]interface{} `json:"model"`
+	Embedded struct {
+		Enrollments []struct {
+			Enrollment map[string]interface{} `json:"enrollment"`
+			Links      []response.Link        `json:"links"`
+		} `json:"enrollments"`
+		Model *struct {
+			Model map[string]interface{} `json:"model"`
+		} `json:"model"`
+	} `json:"embedded"`
+}
+
+func newRelationTestResources() (*mux.Router, *enrollmentRepo) {
+	models := newMemoryRepo(
+		Model{Id: resourceId, Name: types.NewNullString("test", true)},
+		Model{Id: otherResourceId, Name: types.NewNullString("other", true)},
+	)
+	enrollments := &enrollmentRepo{enrollments: []Enrollment{{"e1", resourceId}, {"e2", resourceId}, {"e3", "missing"}}}
+
+	router := mux.NewRouter()
+
+	modelResource := NewResource(Model{}, models, validation.Singleton(), "model", resourceRouteNames)
+	modelResource.Relations = []Relation{{
+		Relation: db.Relation{
+			Name:       "enrollments",
+			Type:       db.RELATION_HAS_MANY,
+			Model:      Enrollment{},
+			Repository: enrollments,
+			ForeignKey: "modelId",
+		},
+		ResourceType: "enrollment",
+		RouteNames:   enrollmentRouteNames,
+	}}
+	modelResource.AttachRoutes(router, "/models")
+
+	enrollmentResource := NewResource(Enrollment{}, enrollments, validation.Singleton(), "enrollment", enrollmentRouteNames)
+	enrollmentResource.Relations = []Relation{{
+		Relation: db.Relation{
+			Name:       "model",
+			Type:       db.RELATION_BELONGS_TO,
+			Model:      Model{},
+			Repository: models,
+			ForeignKey: "modelId",
+		},
+		ResourceType: "model",
+	}}
+	enrollmentResource.AttachRoutes(router, "/enrollments")
+
+	return router, enrollments
+}
+
+func TestResource_IncludeHasMany(t *testing.T) {
+	router, _ := newRelationTestResources()
+
+	t.Run("Embed the related models of each item", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models?include=enrollments", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		body := struct {
+			Items []embeddedBody `json:"items"`
+		}{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+
+		counts := map[interface{}]int{}
+		for _, item := range body.Items {
+			counts[item.Model["id"]] = len(item.Embedded.Enrollments)
+		}
+
+		expected := map[interface{}]int{resourceId: 2, otherResourceId: 0}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Errorf("Expected the enrollments of each model, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Embed the related models with their own links and fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models/"+resourceId+"?include=enrollments&fields=name&fields[enrollments]=id", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var body embeddedBody
+		json.Unmarshal(w.Body.Bytes(), &body)
+
+		if len(body.Model) != 1 || body.Model["name"] != "test" {
+			t.Errorf("Expected only the name of the model, got %s", w.Body.String())
+		}
+
+		enrollments := body.Embedded.Enrollments
+		if len(enrollments) != 2 || len(enrollments[0].Enrollment) != 1 || enrollments[0].Enrollment["id"] != "e1" {
+			t.Fatalf("Expected only the id of the enrollments, got %s", w.Body.String())
+		}
+
+		if len(enrollments[0].Links) != 1 || enrollments[0].Links[0].Href != "http://example.com/enrollments/e1" {
+			t.Errorf("Expected the self link of the enrollment, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Return a 400 for a relation or a related field that does not exist", func(t *testing.T) {
+		for _, query := range []string{"include=invalid", "fields[enrollments]=invalid"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/models?"+query, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+}
+
+func TestResource_IncludeBelongsTo(t *testing.T) {
+	router, enrollments := newRelationTestResources()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/enrollments?include=model&fields=id", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	if !reflect.DeepEqual(enrollments.findBy.Fields, []string{"id", "modelId"}) {
+		t.Errorf("Expected the foreign key to be loaded, got %v", enrollments.findBy.Fields)
+	}
+
+	body := struct {
+		Items []struct {
+			Enrollment map[string]interface{} `json:"enrollment"`
+			Embedded   map[string]*struct {
+				Model map[string]interface{} `json:"model"`
+			} `json:"embedded"`
+		} `json:"items"`
+	}{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+
+	if len(body.Items) != 3 {
+		t.Fatalf("Expected 3 enrollments, got %s", w.Body.String())
+	}
+
+	for _, item := range body.Items {
+		model, ok := item.Embedded["model"]
+		if !ok {
+			t.Fatalf("Expected the model to be embedded, got %s", w.Body.String())
+		}
+
+		if item.Enrollment["id"] == "e3" && model != nil {
+			t.Errorf("Expected a null model when it does not exist, got %v", model)
+		} else if item.Enrollment["id"] != "e3" && (model == nil || model.Model["id"] != resourceId) {
+			t.Errorf("Expected model %s, got %v", resourceId, model)
+		}
+	}
+}
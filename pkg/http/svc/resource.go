@@ -0,0 +1,678 @@
+package svc
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gocraft/dbr"
+	"github.com/gorilla/mux"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/middleware"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/pagination"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/route"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/uuid"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+// DEFAULT_MAX_BODY_SIZE is the MaxBodySize of a new Resource, zero so the size of the bodies isn't limited unless the
+// service sets one, e.g. 1 << 20 for 1MB
+const DEFAULT_MAX_BODY_SIZE = 0
+
+// ErrCursorNotSupported is returned for cursor pagination when the repository isn't a db.CursorRepository
+var ErrCursorNotSupported = errors.New("cursor pagination is not supported by this collection")
+
+// Resource provides the standard cget, get, post, patch and delete handlers, and optionally their bulk versions, for a
+// model so that services don't need to write them by hand.
+type Resource struct {
+	// Model is a prototype of the model, it is only used to determine the type to instantiate for each request.
+	Model        interface{}
+	Repository   db.Repository
+	Validator    *validation.Validator
+	ResourceType string
+	RouteNames   map[string]string
+	// CreateFields are the struct members that are allowed to be set on POST.  An empty slice allows all members.
+	CreateFields []string
+	// UpdateFields are the struct members that are allowed to be set on PATCH.  An empty slice allows all members.
+	UpdateFields []string
+	// MaxBodySize is the largest request body in bytes that POST and PATCH accept, zero disables the limit.
+	MaxBodySize int64
+	// Relations are the related models that cget and get can embed with the include parameter
+	Relations []Relation
+	// Bulk adds the POST, PATCH and DELETE routes on /bulk that save many models with a single request
+	Bulk bool
+
+	router    *mux.Router
+	modelType reflect.Type
+}
+
+// NewResource instantiates a Resource for the model prototype that is passed in.
+func NewResource(model interface{}, rep db.Repository, validator *validation.Validator, resourceType string, rm map[string]string) *Resource {
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	if modelType.Kind() != reflect.Struct {
+		panic(errors.New("model should be a struct or a pointer to a struct"))
+	}
+
+	return &Resource{
+		Model:        reflect.New(modelType).Elem().Interface(),
+		Repository:   rep,
+		Validator:    validator,
+		ResourceType: resourceType,
+		RouteNames:   rm,
+		MaxBodySize:  DEFAULT_MAX_BODY_SIZE,
+		modelType:    modelType,
+	}
+}
+
+// AttachRoutes adds all of the resource routes to a subrouter under the path prefix and names them using RouteNames.
+// The bulk routes are only added when Bulk is true.  The subrouter is returned so that middleware and sub-resources can
+// be added to it.
+func (res *Resource) AttachRoutes(r *mux.Router, pathPrefix string) *mux.Router {
+	res.router = r
+
+	sr := r.PathPrefix(pathPrefix).Subrouter()
+	if res.Bulk {
+		if _, ok := res.Repository.(db.BatchRepository); !ok {
+			panic(errors.New("the bulk routes require a repository that implements db.BatchRepository"))
+		}
+
+		// the bulk routes are attached first so that /bulk isn't matched as an id
+		res.name(sr.Path("/bulk").Methods("POST").Handler(res.BulkPostHandler()), route.BULK_POST_ROUTE)
+		res.name(sr.Path("/bulk").Methods("PATCH").Handler(res.BulkPatchHandler()), route.BULK_PATCH_ROUTE)
+		res.name(sr.Path("/bulk").Methods("DELETE").Handler(res.BulkDeleteHandler()), route.BULK_DELETE_ROUTE)
+	}
+	res.name(sr.Path("").Methods("GET").Handler(res.CGetHandler()), route.CGET_ROUTE)
+	res.name(sr.Path("/{id}").Methods("GET").Handler(res.GetHandler()), route.GET_ROUTE)
+	res.name(sr.Path("").Methods("POST").Handler(res.PostHandler()), route.POST_ROUTE)
+	res.name(sr.Path("/{id}").Methods("PATCH").Handler(res.PatchHandler()), route.PATCH_ROUTE)
+	res.name(sr.Path("/{id}").Methods("DELETE").Handler(res.DeleteHandler()), route.DELETE_ROUTE)
+
+	return sr
+}
+
+// CGetHandler returns a paginated collection of models that can be filtered, searched and sorted through the query
+// string.  Collections are paged by page/size unless the request opts into cursor pagination.
+func (res *Resource) CGetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fb := db.FindBy{
+			Conditions: getQueryConditions(r),
+			Search:     make(map[string]interface{}),
+			OrderBy:    make(map[string]interface{}),
+		}
+
+		if err := GetQueryParams(r.URL.RequestURI(), &fb, res.Model, res.Validator); err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		r, fields, err := res.withFields(r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		includes, err := res.getIncludes(r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		// the keys of the included relations are loaded even when they aren't one of the fields
+		fb.Fields = fields
+		for _, rel := range includes {
+			if len(fields) > 0 && rel.Type == db.RELATION_BELONGS_TO {
+				fb.Fields = append(fb.Fields[:len(fb.Fields):len(fb.Fields)], rel.ForeignKey)
+			}
+		}
+
+		p := pagination.NewPagination(r)
+		if p.IsCursor() {
+			res.writeCursorCollection(w, r, p, fb, includes)
+			return
+		}
+
+		count, err := db.CountContext(r.Context(), res.Repository, res.Model, fb)
+		if err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		paging, err := response.NewCollectionPaging(p, count)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		fb.Limit = p.Size()
+		fb.Offset = p.Offset()
+
+		models := reflect.New(reflect.SliceOf(res.modelType))
+		if err := db.FindByContext(r.Context(), res.Repository, models.Interface(), fb); err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		cm := response.CollectionMetadata{
+			Count:  count,
+			Paging: paging,
+			Sort:   getMetadataSort(fb),
+			Filter: getMetadataFilter(fb),
+		}
+
+		cr, err := response.NewModelCollectionResponse(cm, res.RouteNames, res.ResourceType, res.router, r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		res.writeCollection(w, r, cr, models.Elem(), includes)
+	}
+}
+
+// writeCursorCollection writes a page of the collection using keyset pagination.  The next and prev links carry the
+// cursor of the last and first item instead of a page number.  The collection can't be sorted by a nullable field.  The
+// collection isn't counted, a count of the table is what keyset pagination avoids, so the count is the size of the page.
+func (res *Resource) writeCursorCollection(w http.ResponseWriter, r *http.Request, p *pagination.Pagination, fb db.FindBy, includes []Relation) {
+	cursors, ok := res.Repository.(db.CursorRepository)
+	if !ok {
+		WriteBadRequestErrorResponse(w, ErrCursorNotSupported)
+		return
+	}
+
+	cursor, err := p.Cursor()
+	if err != nil {
+		WriteBadRequestErrorResponse(w, err)
+		return
+	}
+
+	// one extra row is loaded to find out if there is another page
+	fb.Limit = p.Size() + 1
+	sorting := fb.Sorting()
+	if err := db.ValidateCursorSorting(res.Model, sorting); err != nil {
+		WriteBadRequestErrorResponse(w, err)
+		return
+	}
+
+	models := reflect.New(reflect.SliceOf(res.modelType))
+	if err := cursors.FindByCursorContext(r.Context(), models.Interface(), fb, cursor); err != nil {
+		if len(cursor.Values) > 0 {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		WriteInternalServerErrorResponse(w)
+		return
+	}
+
+	items := models.Elem()
+	hasMore := items.Len() > int(p.Size())
+	if hasMore && cursor.Before {
+		items = items.Slice(1, items.Len())
+	} else if hasMore {
+		items = items.Slice(0, int(p.Size()))
+	}
+
+	hasNext := hasMore
+	hasPrev := len(cursor.Values) > 0
+	if cursor.Before {
+		hasNext, hasPrev = hasPrev, hasMore
+	}
+
+	fb.Limit = p.Size()
+	cm := response.CollectionMetadata{
+		Count: items.Len(),
+		Paging: response.CollectionPaging{
+			Size: int(p.Size()),
+		},
+		Sort:   getMetadataSort(fb),
+		Filter: getMetadataFilter(fb),
+	}
+
+	cr, err := response.NewModelCollectionResponse(cm, res.RouteNames, res.ResourceType, res.router, r)
+	if err != nil {
+		WriteBadRequestErrorResponse(w, err)
+		return
+	}
+
+	if cgetRoute, ok := res.RouteNames[route.CGET_ROUTE]; ok && items.Len() > 0 {
+		links := []struct {
+			enabled bool
+			rel     string
+			param   string
+			item    reflect.Value
+		}{
+			{hasPrev, "prev", pagination.BEFORE_PARAM, items.Index(0)},
+			{hasNext, "next", pagination.AFTER_PARAM, items.Index(items.Len() - 1)},
+		}
+
+		for _, l := range links {
+			if !l.enabled {
+				continue
+			}
+
+			values, err := db.GetCursorValues(l.item.Interface(), sorting)
+			if err != nil {
+				WriteBadRequestErrorResponse(w, err)
+				return
+			}
+
+			token, err := pagination.EncodeCursor(values)
+			if err != nil {
+				WriteBadRequestErrorResponse(w, err)
+				return
+			}
+
+			query := pagination.PageQuery(r.URL.RawQuery, l.param, token)
+			if err := cr.AddLinkWithRawQuery("GET", l.rel, cgetRoute, mux.Vars(r), query); err != nil {
+				WriteBadRequestErrorResponse(w, err)
+				return
+			}
+		}
+	}
+
+	res.writeCollection(w, r, cr, items, includes)
+}
+
+// writeCollection adds each of the models, with their included relations, to the collection response and writes it
+func (res *Resource) writeCollection(w http.ResponseWriter, r *http.Request, cr response.CollectionResponse, models reflect.Value, includes []Relation) {
+	items := make([]response.SingleResponse, models.Len())
+	for i := range items {
+		sr, err := response.NewModelSingleResponse(models.Index(i).Interface(), res.RouteNames, res.ResourceType, res.router, r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		items[i] = sr
+	}
+
+	if err := res.embed(r, includes, models, items); err != nil {
+		WriteInternalServerErrorResponse(w)
+		return
+	}
+
+	for _, sr := range items {
+		cr.AddItem(sr)
+	}
+
+	WriteCollectionResponse(cr, w, http.StatusOK)
+}
+
+// GetHandler returns the model identified by the `id` route variable.
+func (res *Resource) GetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, _, err := res.withFields(r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		includes, err := res.getIncludes(r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		model := FindModel(res.newModel(), res.Repository, r)
+		if model == nil {
+			Write404ErrorResponse(w)
+			return
+		}
+
+		models := reflect.MakeSlice(reflect.SliceOf(res.modelType), 0, 1)
+		models = reflect.Append(models, reflect.ValueOf(model).Elem())
+
+		sr, err := response.NewModelSingleResponse(models.Index(0).Interface(), res.RouteNames, res.ResourceType, res.router, r)
+		if err != nil {
+			WriteBadRequestErrorResponse(w, err)
+			return
+		}
+
+		items := []response.SingleResponse{sr}
+		if err := res.embed(r, includes, models, items); err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		writeSingleResponse(models.Index(0).Interface(), items[0], w, http.StatusOK)
+	}
+}
+
+// PostHandler decodes, validates and creates a new model from the request body.  An id is generated when one isn't
+// supplied.
+func (res *Resource) PostHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model := res.newModel()
+
+		if _, err := validation.DecodeReader(res.body(r), res.CreateFields, model); errors.Is(err, validation.ErrBodyTooLarge) {
+			WritePayloadTooLargeErrorResponse(w, err)
+			return
+		} else if err != nil {
+			writeRequestErrorResponse(w, r, http.StatusBadRequest, err, res.Validator)
+			return
+		}
+
+		if code, err := res.prepareNewModel(r, model); code == http.StatusInternalServerError {
+			WriteInternalServerErrorResponse(w)
+			return
+		} else if err != nil {
+			writeRequestErrorResponse(w, r, code, err, res.Validator)
+			return
+		}
+
+		modelValue := reflect.ValueOf(model).Elem().Interface()
+		if err := db.CreateContext(r.Context(), res.Repository, modelValue); err != nil {
+			WriteInternalServerErrorResponse(w)
+			return
+		}
+
+		WriteSingleResponse(modelValue, res.ResourceType, res.RouteNames, res.router, w, r, http.StatusCreated)
+	}
+}
+
+// decodeNewModel decodes and validates the body of a new model.  An id is generated when one isn't supplied.  The
+// status code of the response is returned along with the error, see validate.
+func (res *Resource) decodeNewModel(r *http.Request, body []byte, model interface{}) (int, error) {
+	if err := validation.DecodeRequest(body, res.CreateFields, model); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return res.prepareNewModel(r, model)
+}
+
+// prepareNewModel generates an id for a decoded model when one isn't supplied and validates it, see validate
+func (res *Resource) prepareNewModel(r *http.Request, model interface{}) (int, error) {
+	id := reflect.ValueOf(model).Elem().FieldByName("Id")
+	if id.IsValid() && id.Kind() == reflect.String && id.String() == "" {
+		id.SetString(uuid.CreateUuidV4())
+	}
+
+	return res.validate(r, model)
+}
+
+// validate validates the model with the context of the request.  The status code of the response is returned along
+// with the error, a 500 when a database rule could not query its table, see validation.QueryError.
+func (res *Resource) validate(r *http.Request, model interface{}) (int, error) {
+	err := validation.StructContext(r.Context(), *res.Validator, model)
+
+	var queryErr *validation.QueryError
+	if errors.As(err, &queryErr) {
+		return http.StatusInternalServerError, err
+	} else if err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	return 0, nil
+}
+
+// PatchHandler applies the request body to the model identified by the `id` route variable, validates and saves it.  A
+// request with an If-Match header that doesn't match the ETag of the model returns 412, see GetModelETag.
+func (res *Resource) PatchHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model := FindModel(res.newModel(), res.Repository, r)
+		if model == nil {
+			Write404ErrorResponse(w)
+			return
+		}
+
+		if ifMatchFails(r, model) {
+			WritePreconditionFailedErrorResponse(w, ErrPreconditionFailed)
+			return
+		}
+
+		patch, err := decodePatch(r, res.body(r), res.updateFields(), model)
+		if err != nil {
+			if errors.Is(err, validation.ErrBodyTooLarge) {
+				WritePayloadTooLargeErrorResponse(w, err)
+				return
+			} else if errors.Is(err, validation.ErrPatchTestFailed) {
+				WriteConflictErrorResponse(w, err)
+				return
+			}
+
+			writeRequestErrorResponse(w, r, http.StatusBadRequest, err, res.Validator)
+			return
+		}
+
+		// the model is always saved to the row of the route even if the body managed to set another id
+		if id := reflect.ValueOf(model).Elem().FieldByName("Id"); id.IsValid() && id.Kind() == reflect.String {
+			id.SetString(mux.Vars(r)["id"])
+		}
+
+		if code, err := res.validate(r, model); code == http.StatusInternalServerError {
+			WriteInternalServerErrorResponse(w)
+			return
+		} else if err != nil {
+			writeRequestErrorResponse(w, r, code, err, res.Validator)
+			return
+		}
+
+		// only the fields that were set or cleared are saved and the pointer is passed so the model has the new version
+		// when it is tagged with db.VERSION_TAG.  The update only matches the version that If-Match was checked against.
+		if err := db.UpdateFieldsContext(r.Context(), res.Repository, model, patch.Fields()); err != nil {
+			writeSaveErrorResponse(w, r, err)
+			return
+		}
+
+		WriteSingleResponse(reflect.ValueOf(model).Elem().Interface(), res.ResourceType, res.RouteNames, res.router, w, r, http.StatusOK)
+	}
+}
+
+// updateFields returns the struct members that PATCH can set.  The id is never one of them, it is the id of the route,
+// and neither is the version, see db.VERSION_TAG, which is only changed by the update.
+func (res *Resource) updateFields() []string {
+	fields := res.UpdateFields
+	if len(fields) == 0 {
+		fields = getMemberNames(res.modelType)
+	}
+
+	version, _ := db.GetVersionMember(res.Model)
+
+	valid := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != "Id" && f != version {
+			valid = append(valid, f)
+		}
+	}
+
+	return valid
+}
+
+// getMemberNames returns the names of the exported struct members that a json property can be decoded into, including
+// those of embedded structs
+func getMemberNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if strings.Split(f.Tag.Get("json"), ",")[0] == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && f.Tag.Get("json") == "" && ft.Kind() == reflect.Struct {
+			names = append(names, getMemberNames(ft)...)
+		} else if f.PkgPath == "" {
+			names = append(names, f.Name)
+		}
+	}
+
+	return names
+}
+
+// body returns the request body, which fails with validation.ErrBodyTooLarge once more than MaxBodySize is read
+func (res *Resource) body(r *http.Request) io.Reader {
+	if res.MaxBodySize <= 0 {
+		return r.Body
+	}
+
+	return validation.LimitReader(r.Body, res.MaxBodySize)
+}
+
+// decodePatch applies the body to the model according to the Content-Type of the request.  JSON Merge Patch and JSON
+// Patch are supported along with a partial object.
+func decodePatch(r *http.Request, body io.Reader, validFields []string, model interface{}) (types.Patch, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != validation.MERGE_PATCH_CONTENT_TYPE && mediaType != validation.JSON_PATCH_CONTENT_TYPE {
+		return validation.DecodeReader(body, validFields, model)
+	}
+
+	patch, err := validation.ReadJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaType == validation.MERGE_PATCH_CONTENT_TYPE {
+		return validation.DecodeMergePatch(patch, validFields, model)
+	}
+
+	return validation.DecodeJSONPatch(patch, validFields, model)
+}
+
+// DeleteHandler removes the model identified by the `id` route variable.  A request with an If-Match header that
+// doesn't match the ETag of the model returns 412, see GetModelETag.
+func (res *Resource) DeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model := FindModel(res.newModel(), res.Repository, r)
+		if model == nil {
+			Write404ErrorResponse(w)
+			return
+		}
+
+		if ifMatchFails(r, model) {
+			WritePreconditionFailedErrorResponse(w, ErrPreconditionFailed)
+			return
+		}
+
+		if err := db.DeleteContext(r.Context(), res.Repository, reflect.ValueOf(model).Elem().Interface()); err != nil {
+			writeSaveErrorResponse(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeSaveErrorResponse writes the error of an update or delete.  A *db.ConflictError means the version of the model
+// changed after it was loaded, which is a 412 when the request was conditional and a 409 otherwise.  dbr.ErrNotFound
+// means the row was deleted after it was loaded.
+func writeSaveErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var conflict *db.ConflictError
+	if errors.Is(err, dbr.ErrNotFound) {
+		Write404ErrorResponse(w)
+	} else if !errors.As(err, &conflict) {
+		WriteInternalServerErrorResponse(w)
+	} else if r.Header.Get(middleware.IfMatchHeader) != "" {
+		WritePreconditionFailedErrorResponse(w, ErrPreconditionFailed)
+	} else {
+		WriteConflictErrorResponse(w, err)
+	}
+}
+
+// withFields validates the fields parameters, including those of the relations, and returns the request with the
+// fields of the resource in its context, see response.ContextWithFields, so that the responses only include those
+// properties.
+func (res *Resource) withFields(r *http.Request) (*http.Request, []string, error) {
+	fieldsets := GetFieldsets(r.URL.Query())
+	for name, fields := range fieldsets {
+		if name == "" {
+			continue
+		}
+
+		rel, ok := res.getRelation(name)
+		if !ok {
+			return nil, nil, errors.New(name + ": This relation does not exist.")
+		}
+
+		if err := ValidateFields(fields, rel.Model); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fields := fieldsets[""]
+	if len(fields) == 0 {
+		return r, nil, nil
+	}
+
+	if err := ValidateFields(fields, res.Model); err != nil {
+		return nil, nil, err
+	}
+
+	return r.WithContext(response.ContextWithFields(r.Context(), fields)), fields, nil
+}
+
+// newModel returns a pointer to a new zero value of the model.
+func (res *Resource) newModel() interface{} {
+	return reflect.New(res.modelType).Interface()
+}
+
+// name will name the route if a route name has been configured for it
+func (res *Resource) name(r *mux.Route, routeKey string) {
+	if name, ok := res.RouteNames[routeKey]; ok {
+		r.Name(name)
+	}
+}
+
+// getMetadataSort returns the sort order that was applied to the collection, including the tie-breaker
+func getMetadataSort(fb db.FindBy) []response.CollectionSort {
+	sorting := fb.Sorting()
+	sort := make([]response.CollectionSort, len(sorting))
+	for i, s := range sorting {
+		sort[i] = response.CollectionSort{Field: s.Field, Direction: s.Direction}
+	}
+
+	return sort
+}
+
+// getMetadataFilter combines the exact match conditions with the operator filters so the collection metadata reflects
+// everything the collection was filtered by.  Operator filters are keyed by field and then by operator.
+func getMetadataFilter(fb db.FindBy) map[string]interface{} {
+	filter := make(map[string]interface{}, len(fb.Conditions)+len(fb.Filters))
+	for field, value := range fb.Conditions {
+		filter[field] = value
+	}
+
+	for _, f := range fb.Filters {
+		operators, ok := filter[f.Field].(map[string]interface{})
+		if !ok {
+			operators = make(map[string]interface{})
+			filter[f.Field] = operators
+		}
+
+		operators[f.Operator] = f.Value
+	}
+
+	return filter
+}
+
+// getQueryConditions returns the exact match filters from the query string.  Reserved words and array parameters are
+// ignored, they are handled by GetQueryParams.
+func getQueryConditions(r *http.Request) map[string]interface{} {
+	conditions := make(map[string]interface{})
+
+	for key, values := range r.URL.Query() {
+		if len(values) == 0 || strings.Contains(key, "[") {
+			continue
+		}
+
+		if isReservedParam(key) {
+			continue
+		}
+
+		conditions[key] = values[0]
+	}
+
+	return conditions
+}
@@ -0,0 +1,1082 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/dbr"
+	"github.com/gorilla/mux"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/route"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/validation"
+)
+
+const resourceId = "c24b2909-92e3-4266-ac13-95ac9f24388f"
+
+var resourceRouteNames = map[string]string{
+	route.CGET_ROUTE:   "cget_model",
+	route.GET_ROUTE:    "get_model",
+	route.POST_ROUTE:   "post_model",
+	route.PATCH_ROUTE:  "patch_model",
+	route.DELETE_ROUTE: "delete_model",
+
+	route.BULK_POST_ROUTE:   "bulk_post_model",
+	route.BULK_PATCH_ROUTE:  "bulk_patch_model",
+	route.BULK_DELETE_ROUTE: "bulk_delete_model",
+}
+
+// memoryRepo is an in memory db.Repository used to test the Resource handlers
+type memoryRepo struct {
+	db.BaseRepository
+	models        map[string]Model
+	err           error
+	updatedFields []string
+	bulkFields    [][]string
+	findBy        db.FindBy
+	counts        int
+}
+
+func newMemoryRepo(models ...Model) *memoryRepo {
+	repo := &memoryRepo{models: make(map[string]Model)}
+	for _, m := range models {
+		repo.models[m.Id] = m
+	}
+
+	return repo
+}
+
+func (r *memoryRepo) FindContext(ctx context.Context, object interface{}, id string) error {
+	m, ok := r.models[id]
+	if !ok {
+		return errors.New("not found")
+	}
+
+	*object.(*Model) = m
+
+	return nil
+}
+
+func (r *memoryRepo) FindByContext(ctx context.Context, objects interface{}, fb db.FindBy) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.findBy = fb
+
+	// only the id filter of the bulk handlers is supported
+	var ids map[interface{}]bool
+	for _, f := range fb.Filters {
+		if f.Field == db.PRIMARY_KEY && f.Operator == db.OPERATOR_IN {
+			ids = make(map[interface{}]bool)
+			for _, id := range f.Value.([]interface{}) {
+				ids[id] = true
+			}
+		}
+	}
+
+	for _, m := range r.models {
+		if ids == nil || ids[m.Id] {
+			*objects.(*[]Model) = append(*objects.(*[]Model), m)
+		}
+	}
+
+	return nil
+}
+
+// FindByCursorContext only supports the default sort by id
+func (r *memoryRepo) FindByCursorContext(ctx context.Context, objects interface{}, fb db.FindBy, cursor db.Cursor) error {
+	ids := make([]string, 0, len(r.models))
+	for id := range r.models {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if cursor.Before {
+		sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	}
+
+	page := make([]Model, 0)
+	for _, id := range ids {
+		if len(cursor.Values) > 0 && (id == cursor.Values[0] || (id < cursor.Values[0].(string)) != cursor.Before) {
+			continue
+		}
+
+		if uint64(len(page)) < fb.Limit {
+			page = append(page, r.models[id])
+		}
+	}
+
+	if cursor.Before {
+		for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+			page[i], page[j] = page[j], page[i]
+		}
+	}
+
+	*objects.(*[]Model) = page
+
+	return nil
+}
+
+func (r *memoryRepo) CountContext(ctx context.Context, object interface{}, fb db.FindBy) (int, error) {
+	r.counts++
+
+	return len(r.models), r.err
+}
+
+func (r *memoryRepo) CreateContext(ctx context.Context, object interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	m := object.(Model)
+	r.models[m.Id] = m
+
+	return nil
+}
+
+func (r *memoryRepo) UpdateContext(ctx context.Context, object interface{}) error {
+	return r.CreateContext(ctx, reflect.Indirect(reflect.ValueOf(object)).Interface())
+}
+
+func (r *memoryRepo) UpdateFieldsContext(ctx context.Context, object interface{}, fields []string) error {
+	r.updatedFields = fields
+
+	return r.UpdateContext(ctx, object)
+}
+
+func (r *memoryRepo) DeleteContext(ctx context.Context, object interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	delete(r.models, object.(Model).Id)
+
+	return nil
+}
+
+func (r *memoryRepo) CreateManyContext(ctx context.Context, objects interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, m := range objects.([]Model) {
+		r.models[m.Id] = m
+	}
+
+	return nil
+}
+
+func (r *memoryRepo) UpdateManyContext(ctx context.Context, objects interface{}) error {
+	return r.CreateManyContext(ctx, objects)
+}
+
+func (r *memoryRepo) UpdateFieldsManyContext(ctx context.Context, objects interface{}, fields [][]string) error {
+	r.bulkFields = fields
+
+	return r.UpdateManyContext(ctx, objects)
+}
+
+func (r *memoryRepo) DeleteManyContext(ctx context.Context, objects interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, m := range objects.([]Model) {
+		delete(r.models, m.Id)
+	}
+
+	return nil
+}
+
+func newTestResource(repo db.Repository) (*Resource, *mux.Router) {
+	router := mux.NewRouter()
+	res := NewResource(Model{}, repo, validation.Singleton(), "model", resourceRouteNames)
+	res.AttachRoutes(router, "/models")
+
+	return res, router
+}
+
+func TestNewResource(t *testing.T) {
+	t.Run("Accept a pointer to the model", func(t *testing.T) {
+		res := NewResource(&Model{}, newMemoryRepo(), validation.Singleton(), "model", resourceRouteNames)
+		if _, ok := res.Model.(Model); !ok {
+			t.Errorf("Expected the model prototype to be %s, got %s", reflect.TypeOf(Model{}), reflect.TypeOf(res.Model))
+		}
+	})
+
+	t.Run("Panic when the model is not a struct", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fail()
+			}
+		}()
+		NewResource("model", newMemoryRepo(), validation.Singleton(), "model", resourceRouteNames)
+	})
+}
+
+func TestResource_AttachRoutes(t *testing.T) {
+	_, router := newTestResource(newMemoryRepo())
+	bulkRouter := newBulkTestResource(newMemoryRepo())
+
+	bulk := map[string]bool{route.BULK_POST_ROUTE: true, route.BULK_PATCH_ROUTE: true, route.BULK_DELETE_ROUTE: true}
+	for key, name := range resourceRouteNames {
+		if (router.Get(name) != nil) == bulk[key] {
+			t.Errorf("Expected route %s to be attached only when it isn't a bulk route", name)
+		}
+
+		if bulkRouter.Get(name) == nil {
+			t.Errorf("Expected route %s to be attached with Bulk", name)
+		}
+	}
+}
+
+// basicRepo only implements db.Repository so the handlers have to do without the optional interfaces
+type basicRepo struct {
+	memory *memoryRepo
+}
+
+func (r basicRepo) Find(object interface{}, id string) error {
+	return r.memory.FindContext(context.Background(), object, id)
+}
+
+func (r basicRepo) FindOneBy(object interface{}, fb db.FindBy) error {
+	return errors.New("not supported")
+}
+
+func (r basicRepo) FindBy(objects interface{}, fb db.FindBy) error {
+	return r.memory.FindByContext(context.Background(), objects, fb)
+}
+
+func (r basicRepo) Create(object interface{}) error {
+	return r.memory.CreateContext(context.Background(), object)
+}
+
+func (r basicRepo) Update(object interface{}) error {
+	return r.memory.UpdateContext(context.Background(), object)
+}
+
+func (r basicRepo) Delete(object interface{}) error {
+	return r.memory.DeleteContext(context.Background(), object)
+}
+
+func (r basicRepo) Count(object interface{}, fb db.FindBy) (int, error) {
+	return r.memory.CountContext(context.Background(), object, fb)
+}
+
+func TestResource_BasicRepository(t *testing.T) {
+	memory := newMemoryRepo(Model{Id: resourceId, Name: types.NewNullString("test", true)})
+	_, router := newTestResource(basicRepo{memory})
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		code   int
+	}{
+		{"Return a collection", "GET", "/models", "", http.StatusOK},
+		{"Return a 400 for cursor pagination", "GET", "/models?cursor=", "", http.StatusBadRequest},
+		{"Return a model", "GET", "/models/" + resourceId, "", http.StatusOK},
+		{"Create a model", "POST", "/models", `{"name":"other"}`, http.StatusCreated},
+		{"Update every column of a model", "PATCH", "/models/" + resourceId, `{"name":"updated"}`, http.StatusOK},
+		{"Delete a model", "DELETE", "/models/" + resourceId, "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+
+			if w.Code != tt.code {
+				t.Errorf("Expected status code %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Panic when the bulk routes are attached without a db.BatchRepository", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected a panic")
+			}
+		}()
+		newBulkTestResource(basicRepo{memory})
+	})
+}
+
+func TestResource_CGetHandler(t *testing.T) {
+	t.Run("Return a collection of models", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo(Model{Id: resourceId, Name: types.NewNullString("test", true)}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models?name=test", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		body := struct {
+			Items    []map[string]interface{} `json:"items"`
+			Metadata struct {
+				Count  int                    `json:"count"`
+				Filter map[string]interface{} `json:"filter"`
+			} `json:"metadata"`
+		}{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+
+		if len(body.Items) != 1 || body.Metadata.Count != 1 {
+			t.Errorf("Expected one item, got %s", w.Body.String())
+		}
+
+		if body.Metadata.Filter["name"] != "test" {
+			t.Errorf("Expected the name filter in the metadata, got %v", body.Metadata.Filter)
+		}
+	})
+
+	t.Run("Follow the links to the other pages", func(t *testing.T) {
+		models := make([]Model, 25)
+		for i := range models {
+			models[i] = Model{Id: fmt.Sprintf("%02d", i)}
+		}
+
+		_, router := newTestResource(newMemoryRepo(models...))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models?sort[name]=desc&size=10", nil))
+
+		var body struct {
+			Links []response.Link `json:"links"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+
+		for _, l := range body.Links {
+			if l.Rel != "next" {
+				continue
+			}
+
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", l.Href, nil))
+			if w.Code != http.StatusOK || !strings.Contains(l.Href, "sort[name]=desc") {
+				t.Errorf("Expected the next link %s to keep the sort, got %d: %s", l.Href, w.Code, w.Body.String())
+			}
+
+			return
+		}
+
+		t.Errorf("Expected a next link, got %v", body.Links)
+	})
+
+	t.Run("Return a 400 for invalid query parameters", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models?invalid=test", nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Return a 500 when the repository fails", func(t *testing.T) {
+		repo := newMemoryRepo()
+		repo.err = errors.New("db error")
+		_, router := newTestResource(repo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+
+	t.Run("Only load and return the requested fields", func(t *testing.T) {
+		repo := newMemoryRepo(Model{Id: resourceId, Name: types.NewNullString("test", true)})
+		_, router := newTestResource(repo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models?fields=name", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if !reflect.DeepEqual(repo.findBy.Fields, []string{"name"}) {
+			t.Errorf("Expected the repository to only load the name, got %v", repo.findBy.Fields)
+		}
+
+		body := struct {
+			Items []struct {
+				Model map[string]interface{} `json:"model"`
+			} `json:"items"`
+			Links []response.Link `json:"links"`
+		}{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+
+		if len(body.Items) != 1 || len(body.Items[0].Model) != 1 || body.Items[0].Model["name"] != "test" {
+			t.Errorf("Expected only the name, got %s", w.Body.String())
+		}
+
+		if len(body.Links) == 0 || !strings.Contains(body.Links[0].Href, "fields=name") {
+			t.Errorf("Expected the links to keep the fields, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestResource_CGetHandlerCursor(t *testing.T) {
+	models := make([]Model, 0)
+	for i := 0; i < 25; i++ {
+		models = append(models, Model{Id: fmt.Sprintf("%02d", i)})
+	}
+	repo := newMemoryRepo(models...)
+	_, router := newTestResource(repo)
+
+	type page struct {
+		Items    []map[string]map[string]interface{} `json:"items"`
+		Links    []response.Link                     `json:"links"`
+		Metadata struct {
+			Count int `json:"count"`
+		} `json:"metadata"`
+	}
+
+	get := func(uri string) page {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", uri, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status code %d, got %d: %s", uri, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		p := page{}
+		json.Unmarshal(w.Body.Bytes(), &p)
+
+		return p
+	}
+
+	link := func(p page, rel string) string {
+		for _, l := range p.Links {
+			if l.Rel == rel {
+				return strings.TrimPrefix(l.Href, "http://example.com")
+			}
+		}
+
+		return ""
+	}
+
+	first := get("/models?cursor=&size=10&showProduct=false")
+	if len(first.Items) != 10 || first.Items[0]["model"]["id"] != "00" {
+		t.Fatalf("Unexpected first page %v", first.Items)
+	}
+
+	if link(first, "prev") != "" {
+		t.Error("The first page should not have a prev link")
+	}
+
+	if !strings.Contains(link(first, "next"), "showProduct=false") || strings.Contains(link(first, "next"), "cursor=") {
+		t.Errorf("The next link should keep the query and replace the cursor, got %s", link(first, "next"))
+	}
+
+	second := get(link(first, "next"))
+	if len(second.Items) != 10 || second.Items[0]["model"]["id"] != "10" {
+		t.Fatalf("Unexpected second page %v", second.Items)
+	}
+
+	third := get(link(second, "next"))
+	if len(third.Items) != 5 || link(third, "next") != "" {
+		t.Fatalf("Unexpected last page %v", third.Items)
+	}
+
+	if third.Metadata.Count != 5 || repo.counts != 0 {
+		t.Errorf("Expected the count of the page without counting the collection, got %d and %d counts", third.Metadata.Count, repo.counts)
+	}
+
+	if link(third, "first") != "/models?cursor=&size=10&showProduct=false" || link(third, "last") != "" {
+		t.Errorf("Expected a first link without the cursor and no last link, got %v", third.Links)
+	}
+
+	previous := get(link(third, "prev"))
+	if len(previous.Items) != 10 || previous.Items[0]["model"]["id"] != "10" || link(previous, "next") == "" {
+		t.Fatalf("Unexpected previous page %v", previous.Items)
+	}
+
+	for _, uri := range []string{"/models?after=invalid", "/models?cursor=&sort[name]=asc"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", uri, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: Expected status code %d, got %d", uri, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestResource_GetHandler(t *testing.T) {
+	_, router := newTestResource(newMemoryRepo(Model{Id: resourceId}))
+
+	t.Run("Return the model", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models/"+resourceId, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if !strings.Contains(w.Body.String(), resourceId) {
+			t.Errorf("Expected the model in the response, got %s", w.Body.String())
+		}
+
+		if etag := "W/" + GetETag(w.Body.Bytes()); w.Header().Get("ETag") != etag {
+			t.Errorf("Expected the weak ETag %s of a model without a version, got %s", etag, w.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("Return a 404 when the model does not exist", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models/does-not-exist", nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Only return the requested fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/models/"+resourceId+"?fields=id,showProduct", nil))
+
+		body := struct {
+			Model map[string]interface{} `json:"model"`
+		}{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+
+		if len(body.Model) != 2 || body.Model["id"] != resourceId || body.Model["showProduct"] != false {
+			t.Errorf("Expected only the id and showProduct, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Return a 400 for a field that does not exist", func(t *testing.T) {
+		for _, query := range []string{"fields=id,invalid", "fields[invalid]=id"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/models/"+resourceId+"?"+query, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+}
+
+func TestResource_PostHandler(t *testing.T) {
+	t.Run("Create the model and generate an id", func(t *testing.T) {
+		repo := newMemoryRepo()
+		_, router := newTestResource(repo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"test"}`)))
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		if len(repo.models) != 1 {
+			t.Errorf("Expected the model to be created")
+		}
+	})
+
+	t.Run("Return a 400 for an unknown property", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"invalid":"test"}`)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Return a 400 for a duplicate property", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"test","name":"other"}`)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Return a 413 when the body is larger than MaxBodySize", func(t *testing.T) {
+		res, router := newTestResource(newMemoryRepo())
+		res.MaxBodySize = 10
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"too long"}`)))
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status code %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+	})
+
+	t.Run("Accept a body of any size by default", func(t *testing.T) {
+		res, router := newTestResource(newMemoryRepo())
+		if res.MaxBodySize != 0 {
+			t.Fatalf("Expected no limit, got %d", res.MaxBodySize)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"`+strings.Repeat("a", 2<<20)+`"}`)))
+
+		if w.Code == http.StatusRequestEntityTooLarge {
+			t.Errorf("Did not expect status code %d", w.Code)
+		}
+	})
+
+	t.Run("Return a 400 for anything after the JSON value", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"test"} {}`)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Return a 422 when validation fails", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"a"}`)))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+		}
+	})
+	t.Run("List the invalid fields next to the message", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"a"}`)))
+
+		var er response.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &er)
+
+		expected := []response.FieldError{{Field: "name", Rule: "min", Value: "a", Message: "name must be at least 3 characters in length"}}
+		if !reflect.DeepEqual(er.Error.Errors, expected) {
+			t.Errorf("Expected %v, got %s", expected, w.Body.String())
+		}
+
+		if er.Error.Message != "name: Failed validation for min with value a" {
+			t.Errorf("Expected the legacy message, got %s", er.Error.Message)
+		}
+	})
+
+	t.Run("Keep the legacy message of a value of the wrong type", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"showProduct":"yes"}`)))
+
+		var er response.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &er)
+
+		if er.Error.Message != "json: cannot unmarshal string into Go value of type bool" {
+			t.Errorf("Expected the legacy message without the field, got %s", er.Error.Message)
+		}
+
+		if len(er.Error.Errors) != 1 || er.Error.Errors[0].Field != "showProduct" || er.Error.Errors[0].Rule != validation.RULE_TYPE {
+			t.Errorf("Expected the field in the errors, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Translate the messages to the language of the request", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"a"}`))
+		r.Header.Set("Accept-Language", "es-MX,es;q=0.9,en;q=0.8")
+		router.ServeHTTP(w, r)
+
+		var er response.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &er)
+
+		if len(er.Error.Errors) != 1 || er.Error.Errors[0].Message != "name debe tener al menos 3 caracteres" {
+			t.Errorf("Expected a Spanish message, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Use the messages of the validator of the resource", func(t *testing.T) {
+		v := validation.NewPlaygroundValidator()
+		if err := v.(*validation.PlaygroundValidator).RegisterTranslation("es", "required", "{0} es obligatorio"); err != nil {
+			t.Fatal(err)
+		}
+
+		type requiredModel struct {
+			Id   string `json:"id"`
+			Name string `json:"name" validate:"required"`
+		}
+
+		router := mux.NewRouter()
+		NewResource(requiredModel{}, newMemoryRepo(), &v, "model", resourceRouteNames).AttachRoutes(router, "/models")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/models", strings.NewReader(`{}`))
+		r.Header.Set("Accept-Language", "es")
+		router.ServeHTTP(w, r)
+
+		var er response.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &er)
+
+		if len(er.Error.Errors) != 1 || er.Error.Errors[0].Message != "name es obligatorio" {
+			t.Errorf("Expected the message of the validator, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Return problem details when they are accepted", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo())
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/models", strings.NewReader(`{"invalid":"a"}`))
+		r.Header.Set("Accept", response.PROBLEM_CONTENT_TYPE)
+		router.ServeHTTP(w, r)
+
+		if w.Header().Get("Content-Type") != response.PROBLEM_CONTENT_TYPE {
+			t.Errorf("Expected Content-Type %s, got %s", response.PROBLEM_CONTENT_TYPE, w.Header().Get("Content-Type"))
+		}
+
+		var pr response.ProblemResponse
+		json.Unmarshal(w.Body.Bytes(), &pr)
+
+		if pr.Status != http.StatusBadRequest || len(pr.Errors) != 1 || pr.Errors[0].Rule != validation.RULE_UNKNOWN {
+			t.Errorf("Expected an unknown property problem, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Return a 500 when a database rule can't query its table", func(t *testing.T) {
+		var v validation.Validator = queryErrorValidator{}
+		router := mux.NewRouter()
+		NewResource(Model{}, newMemoryRepo(), &v, "model", resourceRouteNames).AttachRoutes(router, "/models")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/models", strings.NewReader(`{"name":"test"}`)))
+
+		if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "connection refused") {
+			t.Errorf("Expected a 500 without the query error, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+// queryErrorValidator fails every validation as if a database rule could not query its table
+type queryErrorValidator struct {
+	validation.Validator
+}
+
+func (v queryErrorValidator) Var(field interface{}, options interface{}) error {
+	return v.Struct(field)
+}
+
+func (v queryErrorValidator) Struct(s interface{}) error {
+	return &validation.QueryError{Rule: validation.RULE_UNIQUE_IN, Err: errors.New("connection refused")}
+}
+
+func TestResource_PatchHandler(t *testing.T) {
+	repo := newMemoryRepo(Model{Id: resourceId, Name: types.NewNullString("test", true)})
+	res, router := newTestResource(repo)
+	res.UpdateFields = []string{"Name"}
+
+	t.Run("Update the model", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(`{"name":"updated"}`)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if repo.models[resourceId].Name.String.String != "updated" {
+			t.Errorf("Expected the model to be updated, got %v", repo.models[resourceId].Name)
+		}
+
+		if !reflect.DeepEqual(repo.updatedFields, []string{"Name"}) {
+			t.Errorf("Expected only Name to be updated, got %v", repo.updatedFields)
+		}
+	})
+
+	t.Run("Return a 400 when a property is not allowed to be set", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(`{"showProduct":true}`)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Return a 413 when a patch is larger than MaxBodySize", func(t *testing.T) {
+		res.MaxBodySize = 10
+		defer func() { res.MaxBodySize = 0 }()
+
+		for _, contentType := range []string{"application/json", validation.MERGE_PATCH_CONTENT_TYPE} {
+			r := httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(`{"name":"too long"}`))
+			r.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("Expected status code %d for %s, got %d", http.StatusRequestEntityTooLarge, contentType, w.Code)
+			}
+		}
+	})
+
+	t.Run("Return a 422 when validation fails", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(`{"name":"a"}`)))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+		}
+	})
+
+	t.Run("Never update another model through the id of the body", func(t *testing.T) {
+		idRepo := newMemoryRepo(
+			Model{Id: resourceId, Name: types.NewNullString("test", true)},
+			Model{Id: otherResourceId, Name: types.NewNullString("other", true)},
+		)
+		_, idRouter := newTestResource(idRepo)
+
+		bodies := []struct {
+			contentType string
+			body        string
+		}{
+			{"application/json", `{"id":"` + otherResourceId + `","name":"updated"}`},
+			{validation.MERGE_PATCH_CONTENT_TYPE, `{"id":"` + otherResourceId + `","name":"updated"}`},
+			{validation.JSON_PATCH_CONTENT_TYPE, `[{"op":"replace","path":"/id","value":"` + otherResourceId + `"}]`},
+		}
+
+		for _, b := range bodies {
+			r := httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(b.body))
+			r.Header.Set("Content-Type", b.contentType)
+			w := httptest.NewRecorder()
+			idRouter.ServeHTTP(w, r)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d for %s, got %d: %s", http.StatusBadRequest, b.contentType, w.Code, w.Body.String())
+			}
+
+			if idRepo.models[otherResourceId].Name.String.String != "other" || idRepo.models[resourceId].Name.String.String != "test" {
+				t.Errorf("Expected neither model to change for %s, got %v", b.contentType, idRepo.models)
+			}
+		}
+	})
+
+	t.Run("Return a 404 when the model does not exist", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PATCH", "/models/does-not-exist", strings.NewReader(`{}`)))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+	t.Run("Return a 409 when the model was changed by another request", func(t *testing.T) {
+		repo.err = &db.ConflictError{Table: "model", Id: resourceId}
+		defer func() { repo.err = nil }()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(`{"name":"conflict"}`)))
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	patchTests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+		expected    string
+	}{
+		{"Apply a JSON Merge Patch", validation.MERGE_PATCH_CONTENT_TYPE, `{"name":"merged"}`, http.StatusOK, "merged"},
+		{"Apply a JSON Patch", validation.JSON_PATCH_CONTENT_TYPE, `[{"op":"test","path":"/name","value":"merged"},{"op":"replace","path":"/name","value":"patched"}]`, http.StatusOK, "patched"},
+		{"Return a 409 when a JSON Patch test fails", validation.JSON_PATCH_CONTENT_TYPE, `[{"op":"test","path":"/name","value":"other"},{"op":"replace","path":"/name","value":"failed"}]`, http.StatusConflict, "patched"},
+		{"Return a 400 when a JSON Patch touches a property that is not allowed to be set", validation.JSON_PATCH_CONTENT_TYPE, `[{"op":"replace","path":"/showProduct","value":true}]`, http.StatusBadRequest, "patched"},
+		{"Return a 422 when a JSON Merge Patch fails validation", validation.MERGE_PATCH_CONTENT_TYPE, `{"name":"a"}`, http.StatusUnprocessableEntity, "patched"},
+	}
+
+	for _, tt := range patchTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.code {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+
+			if repo.models[resourceId].Name.String.String != tt.expected {
+				t.Errorf("Expected the name to be %s, got %v", tt.expected, repo.models[resourceId].Name)
+			}
+		})
+	}
+}
+
+func TestResource_DeleteHandler(t *testing.T) {
+	repo := newMemoryRepo(Model{Id: resourceId})
+	_, router := newTestResource(repo)
+
+	t.Run("Delete the model", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/models/"+resourceId, nil))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+		}
+
+		if len(repo.models) != 0 {
+			t.Error("Expected the model to be deleted")
+		}
+	})
+
+	t.Run("Return a 404 when the model does not exist", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/models/"+resourceId, nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Return a 404 when the model is deleted after it was loaded", func(t *testing.T) {
+		repo := newMemoryRepo(Model{Id: resourceId})
+		repo.err = dbr.ErrNotFound
+		_, router := newTestResource(repo)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/models/"+resourceId, nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+type VersionedModel struct {
+	Id      string           `json:"id" db:"id" structs:"id"`
+	Name    types.NullString `json:"name" db:"name" structs:"name,omitnested"`
+	Version int              `json:"version" db:"version" structs:"version" version:"true"`
+}
+
+// versionRepo is an in memory db.Repository that checks and increments the version of a VersionedModel like
+// db.BaseRepository.  The stored version is incremented after the model is loaded when changed is true, as if another
+// request updated it in between.
+type versionRepo struct {
+	db.BaseRepository
+	model   *VersionedModel
+	changed bool
+}
+
+func (r *versionRepo) FindContext(ctx context.Context, object interface{}, id string) error {
+	if r.model == nil || r.model.Id != id {
+		return errors.New("not found")
+	}
+
+	*object.(*VersionedModel) = *r.model
+	if r.changed {
+		r.model.Version++
+	}
+
+	return nil
+}
+
+func (r *versionRepo) UpdateFieldsContext(ctx context.Context, object interface{}, fields []string) error {
+	m := object.(*VersionedModel)
+	if m.Version != r.model.Version {
+		return &db.ConflictError{Table: "model", Id: m.Id}
+	}
+
+	m.Version++
+	*r.model = *m
+
+	return nil
+}
+
+func (r *versionRepo) DeleteContext(ctx context.Context, object interface{}) error {
+	if m := object.(VersionedModel); m.Version != r.model.Version {
+		return &db.ConflictError{Table: "model", Id: m.Id}
+	}
+
+	r.model = nil
+
+	return nil
+}
+
+func TestResource_IfMatch(t *testing.T) {
+	repo := &versionRepo{}
+	router := mux.NewRouter()
+	NewResource(VersionedModel{}, repo, validation.Singleton(), "model", resourceRouteNames).AttachRoutes(router, "/models")
+
+	serve := func(method string, ifMatch string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/models/"+resourceId, strings.NewReader(body))
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+		body    string
+		changed bool
+		code    int
+		version int
+		etag    string
+	}{
+		{"Return the version as the ETag", "GET", "", "", false, http.StatusOK, 1, `"1"`},
+		{"Update when the ETag matches", "PATCH", `"1"`, `{"name":"updated"}`, false, http.StatusOK, 2, `"2"`},
+		{"Update when any ETag is allowed", "PATCH", "*", `{"name":"updated"}`, false, http.StatusOK, 2, `"2"`},
+		{"Return a 412 when the ETag changed", "PATCH", `"0"`, `{"name":"updated"}`, false, http.StatusPreconditionFailed, 1, ""},
+		{"Return a 412 for a weak ETag", "PATCH", `W/"1"`, `{"name":"updated"}`, false, http.StatusPreconditionFailed, 1, ""},
+		{"Return a 412 when the model changes after the ETag was checked", "PATCH", `"1"`, `{"name":"updated"}`, true, http.StatusPreconditionFailed, 2, ""},
+		{"Return a 409 when the model changes without an If-Match", "PATCH", "", `{"name":"updated"}`, true, http.StatusConflict, 2, ""},
+		{"Never let the body set the version", "PATCH", "", `{"version":5}`, false, http.StatusBadRequest, 1, ""},
+		{"Return a 412 when deleting a changed model", "DELETE", `"0"`, "", false, http.StatusPreconditionFailed, 1, ""},
+		{"Return a 412 when the model changes before it is deleted", "DELETE", `"1"`, "", true, http.StatusPreconditionFailed, 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.model = &VersionedModel{Id: resourceId, Name: types.NewNullString("test", true), Version: 1}
+			repo.changed = tt.changed
+
+			w := serve(tt.method, tt.ifMatch, tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+
+			if repo.model.Version != tt.version {
+				t.Errorf("Expected version %d, got %d", tt.version, repo.model.Version)
+			}
+
+			if w.Header().Get("ETag") != tt.etag {
+				t.Errorf("Expected ETag %s, got %s", tt.etag, w.Header().Get("ETag"))
+			}
+		})
+	}
+
+	t.Run("Delete when the ETag matches", func(t *testing.T) {
+		repo.model = &VersionedModel{Id: resourceId, Version: 1}
+		repo.changed = false
+
+		if w := serve("DELETE", `"1"`, ""); w.Code != http.StatusNoContent || repo.model != nil {
+			t.Errorf("Expected the model to be deleted, got %d", w.Code)
+		}
+	})
+
+	t.Run("Only match any ETag for a model without a version", func(t *testing.T) {
+		_, router := newTestResource(newMemoryRepo(Model{Id: resourceId}))
+
+		for ifMatch, code := range map[string]int{`"1"`: http.StatusPreconditionFailed, "*": http.StatusOK} {
+			r := httptest.NewRequest("PATCH", "/models/"+resourceId, strings.NewReader(`{"name":"updated"}`))
+			r.Header.Set("If-Match", ifMatch)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != code {
+				t.Errorf("%s: Expected status code %d, got %d", ifMatch, code, w.Code)
+			}
+		}
+	})
+}
@@ -1,11 +1,16 @@
 package svc
 
 import (
+	"crypto/sha1"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/fatih/structs"
+	"github.com/go-playground/universal-translator"
 	"github.com/gorilla/mux"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/middleware"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/pagination"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/route"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/response"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
@@ -13,8 +18,12 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 )
 
+// ErrPreconditionFailed is returned when the If-Match header of a request doesn't match the ETag of the model
+var ErrPreconditionFailed = errors.New("the model was modified since it was loaded")
+
 // Parse through array type query parameters
 func GetQueryParams(uri string, fb *db.FindBy, model interface{}, validator *validation.Validator) error {
 	filters := strings.Split(uri, "?")
@@ -32,6 +41,11 @@ func GetQueryParams(uri string, fb *db.FindBy, model interface{}, validator *val
 				key := filter[0:openBracketIndex]
 				field := filter[openBracketIndex+1 : closeBracketIndex]
 
+				// the fields of embedded relations are validated by the handler, see GetFieldsets
+				if key == FIELDS_PARAM {
+					continue
+				}
+
 				// Checks if `=` was omitted from the parameter
 				if equalIndex == -1 {
 					return errors.New(key + ": '" + field + "' field cannot be blank.")
@@ -73,6 +87,18 @@ func GetQueryParams(uri string, fb *db.FindBy, model interface{}, validator *val
 					}
 
 					fb.OrderBy[field] = value
+					fb.Sort = appendSort(fb.Sort, db.Sort{Field: field, Direction: value})
+					break
+				case "filter":
+					// The operator is optional, `filter[field]=value` is the same as `filter[field][eq]=value`
+					operator := strings.Trim(filter[closeBracketIndex+1:equalIndex], "[]")
+
+					f, err := parseFilter(model, field, operator, value, validator)
+					if err != nil {
+						return err
+					}
+
+					fb.Filters = append(fb.Filters, f)
 					break
 				default:
 					return errors.New(key + ": This property does not exist.")
@@ -91,7 +117,7 @@ func GetQueryParams(uri string, fb *db.FindBy, model interface{}, validator *val
 				value := filter[equalIndex+1:]
 
 				// Ignore reserved words.
-				if key == "sort" || key == "page" || key == "size" || key == "search" {
+				if isReservedParam(key) {
 					continue
 				}
 
@@ -124,10 +150,102 @@ func GetQueryParams(uri string, fb *db.FindBy, model interface{}, validator *val
 	return nil
 }
 
-// WriteErrorResponse will construct and write a json encoded ErrorResponse to the Response Writer
+// reservedParams are query parameters that are not model properties
+var reservedParams = []string{
+	"sort",
+	"search",
+	"filter",
+	FIELDS_PARAM,
+	INCLUDE_PARAM,
+	pagination.PAGE_PARAM,
+	pagination.SIZE_PARAM,
+	pagination.CURSOR_PARAM,
+	pagination.AFTER_PARAM,
+	pagination.BEFORE_PARAM,
+}
+
+// isReservedParam returns true if the query parameter is not a model property
+func isReservedParam(key string) bool {
+	for _, p := range reservedParams {
+		if p == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendSort adds the sort to the end of the list, preserving the order of the query string.  A field that is sorted
+// more than once keeps its first position and takes the last direction.
+func appendSort(sorts []db.Sort, s db.Sort) []db.Sort {
+	for i := range sorts {
+		if sorts[i].Field == s.Field {
+			sorts[i].Direction = s.Direction
+			return sorts
+		}
+	}
+
+	return append(sorts, s)
+}
+
+// WriteErrorResponse will construct and write a json encoded ErrorResponse to the Response Writer.  The invalid fields
+// are listed in `errors` with English messages when err contains validation.Errors.
 func WriteErrorResponse(w http.ResponseWriter, code int, err error) {
+	writeErrorResponse(w, code, err, validation.GetTranslator(validation.DEFAULT_LOCALE))
+}
+
+// WriteProblemResponse will construct and write an RFC 7807 ProblemResponse to the Response Writer
+func WriteProblemResponse(w http.ResponseWriter, code int, err error) {
+	writeProblemResponse(w, code, err, validation.GetTranslator(validation.DEFAULT_LOCALE))
+}
+
+// WriteRequestErrorResponse writes a ProblemResponse when the client accepts problem+json and an ErrorResponse
+// otherwise.  The messages of the invalid fields are in the language of the Accept-Language header.
+func WriteRequestErrorResponse(w http.ResponseWriter, r *http.Request, code int, err error) {
+	writeRequestErrorResponse(w, r, code, err, validation.Singleton())
+}
+
+// writeRequestErrorResponse is WriteRequestErrorResponse with the messages of the validator that found the errors
+func writeRequestErrorResponse(w http.ResponseWriter, r *http.Request, code int, err error, v *validation.Validator) {
+	trans := validation.GetValidatorTranslator(v, r.Header.Get("Accept-Language"))
+
+	if strings.Contains(r.Header.Get("Accept"), response.PROBLEM_CONTENT_TYPE) {
+		writeProblemResponse(w, code, err, trans)
+		return
+	}
+
+	writeErrorResponse(w, code, err, trans)
+}
+
+func writeErrorResponse(w http.ResponseWriter, code int, err error, trans ut.Translator) {
 	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(response.NewErrorResponse(code, err.Error()))
+	json.NewEncoder(w).Encode(response.NewValidationErrorResponse(code, err.Error(), getFieldErrors(err, trans)))
+}
+
+func writeProblemResponse(w http.ResponseWriter, code int, err error, trans ut.Translator) {
+	w.Header().Set("Content-Type", response.PROBLEM_CONTENT_TYPE)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response.NewProblemResponse(code, err.Error(), getFieldErrors(err, trans)))
+}
+
+// getFieldErrors converts validation.Errors into the errors of a response.  Nil is returned for any other error.
+func getFieldErrors(err error, trans ut.Translator) []response.FieldError {
+	var validationErrs validation.Errors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fieldErrs := make([]response.FieldError, len(validationErrs))
+	for i, e := range validationErrs {
+		fieldErrs[i] = response.FieldError{
+			Field:   e.Field(),
+			Rule:    e.Rule(),
+			Value:   e.Value(),
+			Message: validation.Translate(e, trans),
+		}
+	}
+
+	return fieldErrs
 }
 
 // WriteBadRequestErrorResponse will construct and write a json encoded ErrorResponse to the Response Writer with a 400 error
@@ -140,6 +258,46 @@ func Write404ErrorResponse(w http.ResponseWriter) {
 	WriteErrorResponse(w, http.StatusNotFound, NotFound404)
 }
 
+// WriteUnprocessableEntityErrorResponse will construct and write a json encoded ErrorResponse to the Response Writer
+// with a 422 error
+func WriteUnprocessableEntityErrorResponse(w http.ResponseWriter, err error) {
+	WriteErrorResponse(w, http.StatusUnprocessableEntity, err)
+}
+
+// WriteConflictErrorResponse will construct and write a json encoded ErrorResponse to the Response Writer with a 409
+// error
+func WriteConflictErrorResponse(w http.ResponseWriter, err error) {
+	WriteErrorResponse(w, http.StatusConflict, err)
+}
+
+// WritePayloadTooLargeErrorResponse will write a 413 error response
+func WritePayloadTooLargeErrorResponse(w http.ResponseWriter, err error) {
+	WriteErrorResponse(w, http.StatusRequestEntityTooLarge, err)
+}
+
+// WritePreconditionFailedErrorResponse will write a 412 error response
+func WritePreconditionFailedErrorResponse(w http.ResponseWriter, err error) {
+	WriteErrorResponse(w, http.StatusPreconditionFailed, err)
+}
+
+// WriteInternalServerErrorResponse will construct and write a json encoded ErrorResponse to the Response Writer with a
+// 500 error.  The underlying error is not exposed to the consumer.
+func WriteInternalServerErrorResponse(w http.ResponseWriter) {
+	WriteErrorResponse(w, http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError)))
+}
+
+// WriteCollectionResponse will write a json encoded CollectionResponse to the Response Writer
+func WriteCollectionResponse(cr response.CollectionResponse, w http.ResponseWriter, successfulStatusCode int) {
+	resp, err := json.Marshal(cr)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(successfulStatusCode)
+	w.Write(resp)
+}
+
 // WriteSingleResponse will construct and write a json encoded SingleResponse to the Response Writer
 func WriteSingleResponse(model interface{}, resourceType string, rm map[string]string, router *mux.Router, w http.ResponseWriter, r *http.Request, successfulStatusCode int) {
 	sr, err := response.NewModelSingleResponse(model, rm, resourceType, router, r)
@@ -148,21 +306,58 @@ func WriteSingleResponse(model interface{}, resourceType string, rm map[string]s
 		return
 	}
 
+	writeSingleResponse(model, sr, w, successfulStatusCode)
+}
+
+// writeSingleResponse writes the json encoded SingleResponse along with the ETag of the model
+func writeSingleResponse(model interface{}, sr response.SingleResponse, w http.ResponseWriter, successfulStatusCode int) {
 	resp, err := json.Marshal(sr)
 	if err != nil {
 		WriteErrorResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
+	w.Header().Set(middleware.ETagHeader, GetModelETag(model, resp))
 	w.WriteHeader(successfulStatusCode)
 	w.Write(resp)
 }
 
+// GetModelETag returns the ETag of the model.  A versioned model, see db.VERSION_TAG, has a strong ETag of its version
+// so it is the same for every representation of the row and changes with every update.  Any other model has a weak
+// ETag of the response body, which never matches If-Match.
+func GetModelETag(model interface{}, body []byte) string {
+	version, ok, err := db.GetVersion(model)
+	if !ok || err != nil {
+		return "W/" + GetETag(body)
+	}
+
+	switch v := version.(type) {
+	case nil:
+		return `"0"`
+	case time.Time:
+		return fmt.Sprintf("\"%s\"", v.UTC().Format(time.RFC3339Nano))
+	}
+
+	return fmt.Sprintf("\"%v\"", version)
+}
+
+// GetETag returns a strong ETag for the response body.  It changes whenever the representation of the model does.
+func GetETag(body []byte) string {
+	return fmt.Sprintf("\"%x\"", sha1.Sum(body))
+}
+
+// ifMatchFails returns true when the request has an If-Match header that doesn't match the ETag of the model
+func ifMatchFails(r *http.Request, model interface{}) bool {
+	ifMatch := r.Header.Get(middleware.IfMatchHeader)
+
+	return ifMatch != "" && !middleware.ETagMatches(ifMatch, GetModelETag(model, nil), false)
+}
+
 // FindModel will use the `id` variable from the url to attempt to find a model from the repository.  If none is found
 // nil is returned.
 func FindModel(model interface{}, rep db.Repository, r *http.Request) interface{} {
 	vars := mux.Vars(r)
-	err := rep.Find(model, vars["id"])
+	err := db.FindContext(r.Context(), rep, model, vars["id"])
 	if err != nil {
 		return nil
 	}
@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,7 +28,7 @@ type mockRepo struct {
 	db.BaseRepository
 }
 
-func (r mockRepo) Find(object interface{}, id string) error {
+func (r mockRepo) FindContext(ctx context.Context, object interface{}, id string) error {
 	if err := r.IsPointer(object); err != nil {
 		return err
 	}
@@ -321,6 +322,29 @@ func TestValidSort(t *testing.T) {
 	}
 }
 
+func TestSortPreservesQueryOrder(t *testing.T) {
+	fb := &db.FindBy{
+		OrderBy: make(map[string]interface{}),
+	}
+
+	validator := validation.Singleton()
+	model := Model{}
+	params := GetQueryParams("?sort[showProduct]=desc&sort[name]=asc&sort[showProduct]=asc", fb, model, validator)
+
+	if params != nil {
+		t.Errorf("Expected nil, got %v", params)
+	}
+
+	expected := []db.Sort{
+		{Field: "showProduct", Direction: "asc"},
+		{Field: "name", Direction: "asc"},
+	}
+
+	if !reflect.DeepEqual(fb.Sort, expected) {
+		t.Errorf("Expected %v, got %v", expected, fb.Sort)
+	}
+}
+
 func TestWriteBadRequestErrorResponse(t *testing.T) {
 	w := httptest.NewRecorder()
 	WriteBadRequestErrorResponse(w, errors.New("error, error"))
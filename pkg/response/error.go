@@ -1,14 +1,37 @@
 package response
 
+import "net/http"
+
+// PROBLEM_CONTENT_TYPE is the media type of a ProblemResponse, see RFC 7807
+const PROBLEM_CONTENT_TYPE = "application/problem+json"
+
 type errorObj struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
 }
 
 type ErrorResponse struct {
 	Error errorObj `json:"error"`
 }
 
+// FieldError describes why a single field of the request is invalid
+type FieldError struct {
+	Field   string      `json:"field"`
+	Rule    string      `json:"rule"`
+	Value   interface{} `json:"value"`
+	Message string      `json:"message"`
+}
+
+// ProblemResponse is an RFC 7807 problem details object with the invalid fields as the `errors` extension member
+type ProblemResponse struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
 // NewErrorResponse Creates a new instance of ErrorResponse
 func NewErrorResponse(statusCode int, message string) ErrorResponse {
 	return ErrorResponse{
@@ -18,3 +41,22 @@ func NewErrorResponse(statusCode int, message string) ErrorResponse {
 		},
 	}
 }
+
+// NewValidationErrorResponse creates an ErrorResponse that lists the invalid fields next to the legacy message
+func NewValidationErrorResponse(statusCode int, message string, errs []FieldError) ErrorResponse {
+	er := NewErrorResponse(statusCode, message)
+	er.Error.Errors = errs
+
+	return er
+}
+
+// NewProblemResponse creates a ProblemResponse without a specific problem type so the title is the status text
+func NewProblemResponse(statusCode int, detail string, errs []FieldError) ProblemResponse {
+	return ProblemResponse{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+		Errors: errs,
+	}
+}
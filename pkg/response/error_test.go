@@ -1,7 +1,9 @@
 package response
 
 import (
+	"encoding/json"
 	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -18,7 +20,42 @@ func TestNewErrorResponse(t *testing.T) {
 		},
 	}
 
-	if output != expected {
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+
+	b, _ := json.Marshal(output)
+	if string(b) != `{"error":{"code":400,"message":"Bad request"}}` {
+		t.Errorf("expected errors to be omitted, got %s", b)
+	}
+}
+
+func TestNewValidationErrorResponse(t *testing.T) {
+	errs := []FieldError{{Field: "name", Rule: "min", Value: "a", Message: "Failed validation for min with value a"}}
+
+	output := NewValidationErrorResponse(http.StatusUnprocessableEntity, "name: Failed validation for min with value a", errs)
+
+	b, _ := json.Marshal(output)
+	expected := `{"error":{"code":422,"message":"name: Failed validation for min with value a","errors":[{"field":"name","rule":"min","value":"a","message":"Failed validation for min with value a"}]}}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
+
+func TestNewProblemResponse(t *testing.T) {
+	errs := []FieldError{{Field: "name", Rule: "min", Value: "a", Message: "Failed validation for min with value a"}}
+
+	output := NewProblemResponse(http.StatusUnprocessableEntity, "name: Failed validation for min with value a", errs)
+
+	expected := ProblemResponse{
+		Type:   "about:blank",
+		Title:  "Unprocessable Entity",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "name: Failed validation for min with value a",
+		Errors: errs,
+	}
+
+	if !reflect.DeepEqual(output, expected) {
 		t.Errorf("expected %v, got %v", expected, output)
 	}
 }
@@ -0,0 +1,49 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type fieldsKey struct{}
+
+// ContextWithFields returns a copy of the context that carries the properties model responses are limited to
+func ContextWithFields(ctx context.Context, fields []string) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// FieldsFromContext returns the properties added by ContextWithFields
+func FieldsFromContext(ctx context.Context) ([]string, bool) {
+	fields, ok := ctx.Value(fieldsKey{}).([]string)
+
+	return fields, ok && len(fields) > 0
+}
+
+// SetFields limits the model of the response to the properties, the json names of the struct members.  All of the
+// properties are included when fields is empty.
+func (sr *SingleResponse) SetFields(fields []string) {
+	sr.fields = fields
+}
+
+// selectFields returns the properties of the model that are listed in fields
+func selectFields(model interface{}, fields []string) (interface{}, error) {
+	b, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(b, &properties); err != nil {
+		// only objects have properties to select
+		return model, nil
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if value, ok := properties[f]; ok {
+			selected[f] = value
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,49 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestFieldsFromContext(t *testing.T) {
+	if _, ok := FieldsFromContext(context.Background()); ok {
+		t.Error("Did not expect fields without ContextWithFields")
+	}
+
+	fields := []string{"id", "name"}
+	if actual, ok := FieldsFromContext(ContextWithFields(context.Background(), fields)); !ok || !reflect.DeepEqual(actual, fields) {
+		t.Errorf("Expected %v, got %v", fields, actual)
+	}
+}
+
+func TestSingleResponse_SetFields(t *testing.T) {
+	type model struct {
+		Id    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req = req.WithContext(ContextWithFields(req.Context(), []string{"name", "email", "missing"}))
+
+	sr, _ := NewModelSingleResponse(model{"1", "test", "test@example.com"}, nil, RESOURCE_TYPE, mux.NewRouter(), req)
+
+	b, err := json.Marshal(sr)
+	if err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	if string(b) != `{"model":{"email":"test@example.com","name":"test"}}` {
+		t.Errorf("Expected only the name and email, got %s", b)
+	}
+
+	sr.SetFields(nil)
+	if b, _ := json.Marshal(sr); string(b) != `{"model":{"id":"1","name":"test","email":"test@example.com"}}` {
+		t.Errorf("Expected all of the fields, got %s", b)
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/pagination"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/route"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -55,9 +59,24 @@ func (r *Resource) GetResourceType() string {
 type SingleResponse struct {
 	ResourceLinks
 	Resource
+	// status and err are only set for the items of a bulk response
+	status   int
+	err      *errorObj
+	fields   []string
+	embedded map[string]interface{}
 }
 
 func (rl *ResourceLinks) AddLink(method string, rel string, routeName string, routeParams map[string]string) error {
+	return rl.AddLinkWithQuery(method, rel, routeName, routeParams, nil)
+}
+
+// AddLinkWithQuery adds a link the same way as AddLink and appends the query to the generated url
+func (rl *ResourceLinks) AddLinkWithQuery(method string, rel string, routeName string, routeParams map[string]string, query url.Values) error {
+	return rl.AddLinkWithRawQuery(method, rel, routeName, routeParams, query.Encode())
+}
+
+// AddLinkWithRawQuery adds a link the same way as AddLink and appends the already encoded query to the generated url
+func (rl *ResourceLinks) AddLinkWithRawQuery(method string, rel string, routeName string, routeParams map[string]string, rawQuery string) error {
 	routePtr := rl.getRouter().Get(routeName)
 	if routePtr == nil {
 		return errors.New(fmt.Sprintf("Route %s does not exist", routeName))
@@ -74,6 +93,10 @@ func (rl *ResourceLinks) AddLink(method string, rel string, routeName string, ro
 		return err
 	}
 
+	if rawQuery != "" {
+		url.RawQuery = rawQuery
+	}
+
 	url.Host = rl.getRequest().Host
 	url.Scheme = "http"
 	if rl.request.URL.Scheme != "" {
@@ -106,14 +129,70 @@ func CreateSingleResponse(modelResp interface{}, resourceType string, router *mu
 	}, nil
 }
 
+// NewItemErrorResponse creates the item of a bulk response for a model that could not be saved.  The item has the
+// status and the error but no model.
+func NewItemErrorResponse(statusCode int, message string, errs []FieldError, resourceType string, router *mux.Router, req *http.Request) (SingleResponse, error) {
+	sr, err := CreateSingleResponse(nil, resourceType, router, req)
+	if err != nil {
+		return sr, err
+	}
+
+	er := NewValidationErrorResponse(statusCode, message, errs)
+	sr.status = statusCode
+	sr.err = &er.Error
+
+	return sr, nil
+}
+
+// Embed adds the response of a related model under `embedded`.  related is a SingleResponse for a belongs to relation,
+// or nil when there is no related model, and a slice of SingleResponse for a has many relation.
+func (sr *SingleResponse) Embed(name string, related interface{}) {
+	if sr.embedded == nil {
+		sr.embedded = make(map[string]interface{})
+	}
+
+	sr.embedded[name] = related
+}
+
+// SetStatus sets the status of the item of a bulk response
+func (sr *SingleResponse) SetStatus(statusCode int) {
+	sr.status = statusCode
+}
+
+// GetStatus returns the status of the item of a bulk response, zero for any other response
+func (sr SingleResponse) GetStatus() int {
+	return sr.status
+}
+
 func (sr SingleResponse) MarshalJSON() ([]byte, error) {
 	responseObj := make(map[string]interface{}, 0)
-	responseObj[sr.GetResourceType()] = sr.getModelResponse()
+	if model := sr.getModelResponse(); model != nil && len(sr.fields) > 0 {
+		selected, err := selectFields(model, sr.fields)
+		if err != nil {
+			return nil, err
+		}
+
+		responseObj[sr.GetResourceType()] = selected
+	} else if model != nil {
+		responseObj[sr.GetResourceType()] = model
+	}
 
 	if len(sr.GetLinks()) > 0 {
 		responseObj["links"] = sr.GetLinks()
 	}
 
+	if len(sr.embedded) > 0 {
+		responseObj["embedded"] = sr.embedded
+	}
+
+	if sr.status != 0 {
+		responseObj["status"] = sr.status
+	}
+
+	if sr.err != nil {
+		responseObj["error"] = sr.err
+	}
+
 	return json.Marshal(responseObj)
 }
 
@@ -123,10 +202,19 @@ type CollectionPaging struct {
 	Pages   int `json:"pages"`
 }
 
+// CollectionSort is a single field that the collection is sorted by.  The order of the CollectionSort slice is the
+// order the sort was applied in.
+type CollectionSort struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction"`
+}
+
+// CollectionMetadata describes the collection of a response.  Sort was a map keyed by field before it became a slice
+// of CollectionSort, which keeps the order of the sorts.
 type CollectionMetadata struct {
 	Count  int                    `json:"count"`
 	Paging CollectionPaging       `json:"paging"`
-	Sort   map[string]interface{} `json:"sort"`
+	Sort   []CollectionSort       `json:"sort"`
 	Filter map[string]interface{} `json:"filter"`
 }
 
@@ -170,10 +258,19 @@ func (cr CollectionResponse) MarshalJSON() ([]byte, error) {
 	return json.Marshal(responseObj)
 }
 
-// NewModelSingleResponse creates a new SingleResponse specifically for instance objects
+// NewModelSingleResponse creates a new SingleResponse specifically for instance objects.  The datetimes of the model
+// are rendered in the location of the request context, see types.ContextWithLocation, and the model is limited to the
+// fields of the request context, see ContextWithFields.
 func NewModelSingleResponse(model interface{}, rm map[string]string, resourceType string, router *mux.Router, req *http.Request) (SingleResponse, error) {
 	errs := make([]string, 0)
+	if loc, ok := types.LocationFromContext(req.Context()); ok {
+		model = types.InLocation(model, loc)
+	}
+
 	sr, _ := CreateSingleResponse(model, resourceType, router, req)
+	if fields, ok := FieldsFromContext(req.Context()); ok {
+		sr.SetFields(fields)
+	}
 
 	routeParams := mux.Vars(req)
 	if routeParams == nil {
@@ -184,7 +281,6 @@ func NewModelSingleResponse(model interface{}, rm map[string]string, resourceTyp
 		routeParams["id"] = s.FieldByName("Id").String()
 	}
 
-
 	if _, ok := rm[route.CGET_ROUTE]; ok {
 		if linkErr := sr.AddLink("GET", "parent", rm[route.CGET_ROUTE], routeParams); linkErr != nil {
 			errs = append(errs, linkErr.Error())
@@ -216,7 +312,16 @@ func NewModelSingleResponse(model interface{}, rm map[string]string, resourceTyp
 	return sr, nil
 }
 
-// NewConfigCollectionResponse creates a new CollectionResponse specifically for instance objects
+// pageLink is a collection link to a page, a page of 0 links to the start of the collection
+type pageLink struct {
+	rel  string
+	page int
+}
+
+// NewModelCollectionResponse creates a new CollectionResponse specifically for instance objects.  The self, first,
+// last, prev and next links keep the query string of the request so filters, searches and sorts are preserved.  When
+// the metadata has paging, the links point to their page and prev and next are only added when those pages exist.  A
+// cursor request only gets a first link, the prev and next links of a cursor collection are added by the caller.
 func NewModelCollectionResponse(cm CollectionMetadata, rm map[string]string, resourceType string, router *mux.Router, req *http.Request) (CollectionResponse, error) {
 	errs := make([]string, 0)
 	cr, _ := CreateCollectionResponse(cm, resourceType, router, req)
@@ -224,16 +329,37 @@ func NewModelCollectionResponse(cm CollectionMetadata, rm map[string]string, res
 	routeParams := mux.Vars(req)
 
 	if _, ok := rm[route.CGET_ROUTE]; ok {
-		if linkErr := cr.AddLink("GET", "self", rm[route.CGET_ROUTE], routeParams); linkErr != nil {
-			errs = append(errs, linkErr.Error())
+		pageLinks := []pageLink{{"first", 0}, {"last", 0}}
+		if cm.Paging.Current > 0 {
+			pageLinks = []pageLink{{"first", 1}, {"last", cm.Paging.Pages}}
+
+			if cm.Paging.Current > 1 {
+				pageLinks = append(pageLinks, pageLink{"prev", cm.Paging.Current - 1})
+			}
+
+			if cm.Paging.Current < cm.Paging.Pages {
+				pageLinks = append(pageLinks, pageLink{"next", cm.Paging.Current + 1})
+			}
 		}
 
-		if linkErr := cr.AddLink("GET", "first", rm[route.CGET_ROUTE], routeParams); linkErr != nil {
+		if linkErr := cr.AddLinkWithRawQuery("GET", "self", rm[route.CGET_ROUTE], routeParams, req.URL.RawQuery); linkErr != nil {
 			errs = append(errs, linkErr.Error())
 		}
 
-		if linkErr := cr.AddLink("GET", "last", rm[route.CGET_ROUTE], routeParams); linkErr != nil {
-			errs = append(errs, linkErr.Error())
+		// a cursor collection starts over with an empty cursor and there is no last page of a keyset
+		if pagination.NewPagination(req).IsCursor() {
+			first := pagination.PageQuery(req.URL.RawQuery, pagination.CURSOR_PARAM, "")
+			if linkErr := cr.AddLinkWithRawQuery("GET", "first", rm[route.CGET_ROUTE], routeParams, first); linkErr != nil {
+				errs = append(errs, linkErr.Error())
+			}
+
+			pageLinks = nil
+		}
+
+		for _, l := range pageLinks {
+			if linkErr := cr.AddLinkWithRawQuery("GET", l.rel, rm[route.CGET_ROUTE], routeParams, getPageQuery(req, l.page)); linkErr != nil {
+				errs = append(errs, linkErr.Error())
+			}
 		}
 	}
 
@@ -249,3 +375,27 @@ func NewModelCollectionResponse(cm CollectionMetadata, rm map[string]string, res
 
 	return cr, nil
 }
+
+// NewCollectionPaging creates the paging metadata for a collection from the request pagination and the total count
+func NewCollectionPaging(p *pagination.Pagination, count int) (CollectionPaging, error) {
+	current, err := p.CurrentPage(count)
+	if err != nil {
+		return CollectionPaging{}, err
+	}
+
+	return CollectionPaging{
+		Current: current,
+		Size:    int(p.Size()),
+		Pages:   p.TotalPages(count),
+	}, nil
+}
+
+// getPageQuery returns the raw query of the request with the page replaced.  The page is removed when it is 0 along
+// with any cursor so the link points to the start of the collection.
+func getPageQuery(req *http.Request, page int) string {
+	if page > 0 {
+		return pagination.PageQuery(req.URL.RawQuery, pagination.PAGE_PARAM, strconv.Itoa(page))
+	}
+
+	return pagination.PageQuery(req.URL.RawQuery, "", "")
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/gorilla/mux"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/pagination"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/http/route"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 const RESOURCE_TYPE = "model"
@@ -162,6 +166,51 @@ func TestSingleResponse_MarshalJSON(t *testing.T) {
 			t.Error("A Response should return an error if it can't be unMarshalled")
 		}
 	})
+
+	t.Run("Include the embedded responses of the related models", func(t *testing.T) {
+		r, _ := CreateSingleResponse(Model{Id: "1"}, "type", router, req)
+		school, _ := CreateSingleResponse(Model{Id: "2"}, "school", router, req)
+		enrollment, _ := CreateSingleResponse(Model{Id: "3"}, "enrollment", router, req)
+		r.Embed("school", school)
+		r.Embed("enrollments", []SingleResponse{enrollment})
+		r.Embed("district", nil)
+
+		b, _ := json.Marshal(r)
+		expected := `{"embedded":{"district":null,"enrollments":[{"enrollment":{"id":"3"}}],"school":{"school":{"id":"2"}}},` +
+			`"type":{"id":"1"}}`
+		if string(b) != expected {
+			t.Errorf("Expected %s, got %s", expected, b)
+		}
+	})
+
+	t.Run("Include the status of a bulk item", func(t *testing.T) {
+		r, _ := CreateSingleResponse(Model{Id: "1"}, "type", router, req)
+		r.SetStatus(http.StatusCreated)
+
+		b, _ := json.Marshal(r)
+		if string(b) != `{"status":201,"type":{"id":"1"}}` {
+			t.Errorf("Expected the status next to the model, got %s", b)
+		}
+	})
+
+	t.Run("Include the error of a bulk item without a model", func(t *testing.T) {
+		errs := []FieldError{{Field: "name", Rule: "min", Value: "a", Message: "name must be at least 3 characters in length"}}
+		r, err := NewItemErrorResponse(http.StatusUnprocessableEntity, "invalid", errs, "type", router, req)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if r.GetStatus() != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, r.GetStatus())
+		}
+
+		b, _ := json.Marshal(r)
+		expected := `{"error":{"code":422,"message":"invalid","errors":[{"field":"name","rule":"min","value":"a",` +
+			`"message":"name must be at least 3 characters in length"}]},"status":422}`
+		if string(b) != expected {
+			t.Errorf("Expected %s, got %s", expected, b)
+		}
+	})
 }
 
 func TestCreateCollectionResponse(t *testing.T) {
@@ -285,6 +334,25 @@ func TestNewModelSingleResponse(t *testing.T) {
 			t.Errorf("An error was expected but none was returned")
 		}
 	})
+
+	t.Run("Render datetimes in the location of the request", func(t *testing.T) {
+		type datetimeModel struct {
+			Id        string         `json:"id"`
+			CreatedAt types.Datetime `json:"createdAt"`
+		}
+
+		loc := time.FixedZone("test", -5*60*60)
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		req = req.WithContext(types.ContextWithLocation(req.Context(), loc))
+		m := datetimeModel{Id: "test-id", CreatedAt: types.Datetime{Time: time.Date(2018, 1, 20, 20, 1, 0, 0, time.UTC)}}
+
+		sr, _ := NewModelSingleResponse(m, map[string]string{}, RESOURCE_TYPE, mux.NewRouter(), req)
+		out, _ := json.Marshal(sr)
+
+		if !strings.Contains(string(out), `"createdAt":"2018-01-20T15:01:00-05:00"`) {
+			t.Errorf("Expected createdAt in the location of the request, got %s", out)
+		}
+	})
 }
 
 func TestNewModelCollectionResponse(t *testing.T) {
@@ -318,3 +386,71 @@ func TestNewModelCollectionResponse(t *testing.T) {
 		}
 	})
 }
+
+func TestNewModelCollectionResponse_PageLinks(t *testing.T) {
+	router := mux.NewRouter()
+	router.Path("/models").Name(routeNames[route.CGET_ROUTE])
+
+	getLinks := func(cm CollectionMetadata, uri string) map[string]string {
+		req := httptest.NewRequest("GET", uri, nil)
+		cr, err := NewModelCollectionResponse(cm, map[string]string{route.CGET_ROUTE: route.CGET_ROUTE}, RESOURCE_TYPE, router, req)
+		if err != nil {
+			t.Errorf("Got an error when one wasn't expected: %s", err.Error())
+		}
+
+		links := make(map[string]string)
+		for _, l := range cr.GetLinks() {
+			links[l.Rel] = l.Href
+		}
+
+		return links
+	}
+
+	t.Run("Links to pages keep the query string", func(t *testing.T) {
+		cm := CollectionMetadata{Paging: CollectionPaging{Current: 2, Size: 10, Pages: 3}}
+		links := getLinks(cm, "http://example.com/models?sort[name]=desc&page=2&size=10&sort[id]=asc&filter[age][gt]=3")
+
+		expected := map[string]string{
+			"self":  "http://example.com/models?sort[name]=desc&page=2&size=10&sort[id]=asc&filter[age][gt]=3",
+			"first": "http://example.com/models?sort[name]=desc&page=1&size=10&sort[id]=asc&filter[age][gt]=3",
+			"last":  "http://example.com/models?sort[name]=desc&page=3&size=10&sort[id]=asc&filter[age][gt]=3",
+			"prev":  "http://example.com/models?sort[name]=desc&page=1&size=10&sort[id]=asc&filter[age][gt]=3",
+			"next":  "http://example.com/models?sort[name]=desc&page=3&size=10&sort[id]=asc&filter[age][gt]=3",
+		}
+
+		if !reflect.DeepEqual(links, expected) {
+			t.Errorf("Expected %v, got %v", expected, links)
+		}
+	})
+
+	t.Run("Prev and next are only added when the pages exist", func(t *testing.T) {
+		links := getLinks(CollectionMetadata{Paging: CollectionPaging{Current: 1, Size: 10, Pages: 1}}, "http://example.com/models")
+
+		if _, ok := links["prev"]; ok {
+			t.Error("The first page should not have a prev link")
+		}
+
+		if _, ok := links["next"]; ok {
+			t.Error("The last page should not have a next link")
+		}
+	})
+}
+
+func TestNewCollectionPaging(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/models?page=2&size=10", nil)
+
+	paging, err := NewCollectionPaging(pagination.NewPagination(req), 25)
+	if err != nil {
+		t.Errorf("Got an error when one wasn't expected: %s", err.Error())
+	}
+
+	expected := CollectionPaging{Current: 2, Size: 10, Pages: 3}
+	if paging != expected {
+		t.Errorf("Expected %v, got %v", expected, paging)
+	}
+
+	_, err = NewCollectionPaging(pagination.NewPagination(req), 0)
+	if err == nil {
+		t.Error("An error was expected for a page that doesn't exist")
+	}
+}
@@ -0,0 +1,27 @@
+package types
+
+import (
+	"gopkg.in/guregu/null.v3"
+)
+
+// NullBool is a wrapper around gopkg.in/guregu/null.v3 null.Bool
+type NullBool struct {
+	null.Bool
+}
+
+// NewNullBool creates a new null bool
+func NewNullBool(b bool, valid bool) NullBool {
+	return NullBool{Bool: null.NewBool(b, valid)}
+}
+
+// UnmarshalJSON unmarshals data into a NullBool if and only if data is a boolean or null
+func (b *NullBool) UnmarshalJSON(data []byte) error {
+	if err := unmarshalStrict(data, "NullBool", func(v interface{}) bool {
+		_, ok := v.(bool)
+		return ok
+	}); err != nil {
+		return err
+	}
+
+	return b.Bool.UnmarshalJSON(data)
+}
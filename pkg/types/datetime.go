@@ -1,6 +1,7 @@
 package types
 
 import (
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"gopkg.in/guregu/null.v3"
@@ -24,20 +25,33 @@ const (
 	FORMAT_DATETIME_INPUT  = "2006-01-02 15:04:05"
 	FORMAT_DATETIME_OUTPUT = "2006-01-02T15:04:05-07:00"
 	FORMAT_DATE            = "2006-01-02"
+	// FORMAT_DATETIME_ISO is accepted for ISO-8601 input without an offset, which is treated as UTC
+	FORMAT_DATETIME_ISO = "2006-01-02T15:04:05"
 )
 
-func parseTimeFromFormat(format string, input string, nullable bool) (*time.Time, error) {
+// datetimeInputFormats are the formats accepted by Datetime and NullDatetime.  RFC3339 also accepts fractional seconds.
+var datetimeInputFormats = []string{time.RFC3339, FORMAT_DATETIME_ISO, FORMAT_DATETIME_INPUT}
+
+// parseTimeFromFormat parses the input with the first of the formats that matches and returns it in UTC
+func parseTimeFromFormat(input string, nullable bool, formats ...string) (*time.Time, error) {
 	// Ignore null, like in the main JSON package.
 	if input == "null" && nullable {
 		return nil, nil
 	}
 
-	t, err := time.Parse(`"`+format+`"`, input)
+	var t time.Time
+	var err error
+	for _, format := range formats {
+		if t, err = time.Parse(`"`+format+`"`, input); err == nil {
+			break
+		}
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	t.UTC()
+	t = t.UTC()
 	if t.Year() <= 0 || t.Year() > 10000 {
 		return nil, errors.New("time year is not a valid year value, must be greater than 1 and less than 10000")
 	}
@@ -64,7 +78,7 @@ func (nd NullDate) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON checks specifically for the time format YYYY-MM-DD or if its null return as such
 func (nd *NullDate) UnmarshalJSON(data []byte) error {
-	t, err := parseTimeFromFormat(FORMAT_DATE, string(data), true)
+	t, err := parseTimeFromFormat(string(data), true, FORMAT_DATE)
 	if err != nil {
 		return err
 	}
@@ -84,9 +98,10 @@ func (ndt NullDatetime) MarshalJSON() ([]byte, error) {
 	return formatNullTime(ndt.Time, FORMAT_DATETIME_OUTPUT)
 }
 
-// UnmarshalJSON checks specifically for the time format YYYY-MM-DD HH:ii:ss or if its null return as such
+// UnmarshalJSON accepts RFC3339, YYYY-MM-DDTHH:ii:ss or YYYY-MM-DD HH:ii:ss and stores the time in UTC.  A null is
+// returned as such.
 func (ndt *NullDatetime) UnmarshalJSON(data []byte) error {
-	t, err := parseTimeFromFormat(FORMAT_DATETIME_INPUT, string(data), true)
+	t, err := parseTimeFromFormat(string(data), true, datetimeInputFormats...)
 	if err != nil {
 		return err
 	}
@@ -106,9 +121,9 @@ func (dt Datetime) MarshalJSON() ([]byte, error) {
 	return formatTime(dt.Time, FORMAT_DATETIME_OUTPUT)
 }
 
-//Unmarshal will attempt to parse the input into a YYYY-MM-DD hh:ii:ss format
+//Unmarshal will attempt to parse RFC3339, YYYY-MM-DDThh:ii:ss or YYYY-MM-DD hh:ii:ss input and store it in UTC
 func (dt *Datetime) UnmarshalJSON(data []byte) error {
-	t, err := parseTimeFromFormat(FORMAT_DATETIME_INPUT, string(data), false)
+	t, err := parseTimeFromFormat(string(data), false, datetimeInputFormats...)
 	if err != nil {
 		return err
 	}
@@ -124,7 +139,7 @@ func (d Date) MarshalJSON() ([]byte, error) {
 
 //Unmarshal will attempt to parse the input into a YYYY-MM-DD format
 func (d *Date) UnmarshalJSON(data []byte) error {
-	t, err := parseTimeFromFormat(FORMAT_DATE, string(data), false)
+	t, err := parseTimeFromFormat(string(data), false, FORMAT_DATE)
 	if err != nil {
 		return err
 	}
@@ -132,3 +147,73 @@ func (d *Date) UnmarshalJSON(data []byte) error {
 	d.Time = *t
 	return nil
 }
+
+// Value implements the driver.Valuer interface, the time is stored in UTC
+func (ndt NullDatetime) Value() (driver.Value, error) {
+	if !ndt.Valid {
+		return nil, nil
+	}
+
+	return ndt.Time.Time.UTC(), nil
+}
+
+// Scan implements the sql.Scanner interface, the time is converted to UTC.  Text is parsed the same as Datetime.
+func (ndt *NullDatetime) Scan(value interface{}) error {
+	if value == nil {
+		ndt.Time = null.Time{}
+		return nil
+	}
+
+	t, err := scanTime(value, "types.NullDatetime")
+	if err != nil {
+		return err
+	}
+
+	ndt.Time = null.TimeFrom(t)
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface, the time is stored in UTC
+func (dt Datetime) Value() (driver.Value, error) {
+	return dt.Time.UTC(), nil
+}
+
+// Scan implements the sql.Scanner interface, the time is converted to UTC.  MySQL returns text unless the connection
+// has parseTime=true, which is parsed as UTC since that is how Value stores it.
+func (dt *Datetime) Scan(value interface{}) error {
+	t, err := scanTime(value, "types.Datetime")
+	if err != nil {
+		return err
+	}
+
+	dt.Time = t
+
+	return nil
+}
+
+// scanTimeFormats are the formats of the datetimes that a database returns as text, the fraction is optional
+var scanTimeFormats = []string{"2006-01-02 15:04:05.999999999", time.RFC3339Nano}
+
+// scanTime returns the time of a time.Time, []byte or string value in UTC
+func scanTime(value interface{}, typeName string) (time.Time, error) {
+	var text string
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return time.Time{}, fmt.Errorf("cannot scan %T into %s", value, typeName)
+	}
+
+	for _, format := range scanTimeFormats {
+		if t, err := time.Parse(format, text); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot scan '%s' into %s", text, typeName)
+}
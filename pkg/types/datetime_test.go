@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	null "gopkg.in/guregu/null.v3"
 )
 
 func TestNullDatetime_UnmarshalJSON(t *testing.T) {
@@ -356,3 +358,110 @@ func TestDate_UnmarshalJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestDatetime_UnmarshalJSONFormats(t *testing.T) {
+	expected := time.Date(2018, 1, 20, 20, 1, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Time
+	}{
+		{"Legacy format", `"2018-01-20 20:01:00"`, expected},
+		{"ISO-8601 without an offset", `"2018-01-20T20:01:00"`, expected},
+		{"RFC3339 in UTC", `"2018-01-20T20:01:00Z"`, expected},
+		{"RFC3339 with an offset", `"2018-01-20T15:01:00-05:00"`, expected},
+		{"RFC3339 with fractional seconds", `"2018-01-20T15:01:00.250-05:00"`, expected.Add(250 * time.Millisecond)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dt := Datetime{}
+			if err := dt.UnmarshalJSON([]byte(test.input)); err != nil {
+				t.Fatalf("Did not expect error and got: %s", err)
+			}
+
+			if !dt.Time.Equal(test.expected) || dt.Time.Location() != time.UTC {
+				t.Errorf("Expected %s, got %s", test.expected, dt.Time)
+			}
+
+			ndt := NullDatetime{}
+			if err := ndt.UnmarshalJSON([]byte(test.input)); err != nil {
+				t.Fatalf("Did not expect error and got: %s", err)
+			}
+
+			if !ndt.Time.Time.Equal(test.expected) || ndt.Time.Time.Location() != time.UTC {
+				t.Errorf("Expected %s, got %s", test.expected, ndt.Time.Time)
+			}
+		})
+	}
+
+	t.Run("Round trip the output", func(t *testing.T) {
+		loc := time.FixedZone("test", -5*60*60)
+		out, _ := Datetime{Time: expected.In(loc)}.MarshalJSON()
+
+		dt := Datetime{}
+		if err := dt.UnmarshalJSON(out); err != nil || !dt.Time.Equal(expected) {
+			t.Errorf("Expected %s to round trip, got %s, %v", out, dt.Time, err)
+		}
+	})
+}
+
+func TestDatetime_ValueAndScan(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	local := time.Date(2018, 1, 20, 20, 1, 0, 0, loc)
+
+	if v, _ := (Datetime{Time: local}).Value(); v.(time.Time).Location() != time.UTC || !v.(time.Time).Equal(local) {
+		t.Errorf("Expected the value in UTC, got %v", v)
+	}
+
+	if v, _ := (NullDatetime{Time: null.TimeFrom(local)}).Value(); v.(time.Time).Location() != time.UTC {
+		t.Errorf("Expected the value in UTC, got %v", v)
+	}
+
+	if v, _ := (NullDatetime{}).Value(); v != nil {
+		t.Errorf("Expected nil for a null datetime, got %v", v)
+	}
+
+	dt := Datetime{}
+	if err := dt.Scan(local); err != nil || dt.Time.Location() != time.UTC {
+		t.Errorf("Expected the scanned time in UTC, got %v, %v", dt.Time, err)
+	}
+
+	if err := dt.Scan("2018-01-20"); err == nil {
+		t.Error("Expected error scanning a date and got none")
+	}
+
+	if err := dt.Scan(12); err == nil {
+		t.Error("Expected error scanning a number and got none")
+	}
+
+	ndt := NullDatetime{}
+	if err := ndt.Scan(local); err != nil || !ndt.Valid || ndt.Time.Time.Location() != time.UTC {
+		t.Errorf("Expected the scanned time in UTC, got %v, %v", ndt.Time, err)
+	}
+
+	if err := ndt.Scan(nil); err != nil || ndt.Valid {
+		t.Errorf("Expected a null datetime, got %v, %v", ndt.Time, err)
+	}
+
+	t.Run("Scan the text that MySQL returns without parseTime", func(t *testing.T) {
+		expected := time.Date(2018, 1, 20, 18, 1, 0, 0, time.UTC)
+		for _, value := range []interface{}{[]byte("2018-01-20 18:01:00"), "2018-01-20 18:01:00", "2018-01-20T20:01:00+02:00"} {
+			dt := Datetime{}
+			if err := dt.Scan(value); err != nil || !dt.Time.Equal(expected) || dt.Time.Location() != time.UTC {
+				t.Errorf("Expected %s for %s, got %s, %v", expected, value, dt.Time, err)
+			}
+
+			ndt := NullDatetime{}
+			if err := ndt.Scan(value); err != nil || !ndt.Valid || !ndt.Time.Time.Equal(expected) {
+				t.Errorf("Expected %s for %s, got %s, %v", expected, value, ndt.Time.Time, err)
+			}
+		}
+
+		dt := Datetime{}
+		if err := dt.Scan([]byte("2018-01-20 18:01:00.123456")); err != nil || dt.Time.Nanosecond() != 123456000 {
+			t.Errorf("Expected the microseconds to be kept, got %s, %v", dt.Time, err)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package types
+
+import (
+	"gopkg.in/guregu/null.v3"
+)
+
+// NullFloat is a wrapper around gopkg.in/guregu/null.v3 null.Float
+type NullFloat struct {
+	null.Float
+}
+
+// NewNullFloat creates a new null float
+func NewNullFloat(f float64, valid bool) NullFloat {
+	return NullFloat{Float: null.NewFloat(f, valid)}
+}
+
+// UnmarshalJSON unmarshals data into a NullFloat if and only if data is a number or null.  Unlike null.Float, strings
+// are not converted.
+func (f *NullFloat) UnmarshalJSON(data []byte) error {
+	if err := unmarshalStrict(data, "NullFloat", func(v interface{}) bool {
+		_, ok := v.(float64)
+		return ok
+	}); err != nil {
+		return err
+	}
+
+	return f.Float.UnmarshalJSON(data)
+}
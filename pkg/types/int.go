@@ -0,0 +1,28 @@
+package types
+
+import (
+	"gopkg.in/guregu/null.v3"
+)
+
+// NullInt is a wrapper around gopkg.in/guregu/null.v3 null.Int
+type NullInt struct {
+	null.Int
+}
+
+// NewNullInt creates a new null int
+func NewNullInt(i int64, valid bool) NullInt {
+	return NullInt{Int: null.NewInt(i, valid)}
+}
+
+// UnmarshalJSON unmarshals data into a NullInt if and only if data is a number or null.  Unlike null.Int, strings are
+// not converted.
+func (i *NullInt) UnmarshalJSON(data []byte) error {
+	if err := unmarshalStrict(data, "NullInt", func(v interface{}) bool {
+		_, ok := v.(float64)
+		return ok
+	}); err != nil {
+		return err
+	}
+
+	return i.Int.UnmarshalJSON(data)
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+)
+
+// NullJSON holds an arbitrary JSON value for json and jsonb columns.  It is null when Valid is false.
+type NullJSON struct {
+	JSON  []byte
+	Valid bool
+}
+
+// NewNullJSON creates a new null json from encoded JSON
+func NewNullJSON(data []byte, valid bool) NullJSON {
+	return NullJSON{JSON: data, Valid: valid}
+}
+
+// MarshalJSON outputs the JSON as it was stored or null if applicable
+func (j NullJSON) MarshalJSON() ([]byte, error) {
+	if !j.Valid {
+		return []byte("null"), nil
+	}
+
+	return j.JSON, nil
+}
+
+// UnmarshalJSON stores any JSON value, null sets Valid to false
+func (j *NullJSON) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		j.JSON, j.Valid = nil, false
+		return nil
+	}
+
+	j.JSON = append([]byte(nil), data...)
+	j.Valid = true
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface.  The JSON is returned as a string because the drivers send []byte as
+// binary data, which json columns don't accept.
+func (j NullJSON) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+
+	return string(j.JSON), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (j *NullJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		j.JSON, j.Valid = nil, false
+	case []byte:
+		j.JSON, j.Valid = append([]byte(nil), v...), true
+	case string:
+		j.JSON, j.Valid = []byte(v), true
+	default:
+		return fmt.Errorf("cannot scan %T into types.NullJSON", value)
+	}
+
+	return nil
+}
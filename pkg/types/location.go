@@ -0,0 +1,90 @@
+package types
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+type locationKey struct{}
+
+// ContextWithLocation returns a copy of the context that carries the location datetimes are rendered in
+func ContextWithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFromContext returns the location added by ContextWithLocation
+func LocationFromContext(ctx context.Context) (*time.Location, bool) {
+	loc, ok := ctx.Value(locationKey{}).(*time.Location)
+
+	return loc, ok && loc != nil
+}
+
+// InLocation returns a copy of v where every Datetime and NullDatetime, including those of nested structs, pointers and
+// slices, is converted to the location.  Dates are left as they are because they don't have a time of day.
+func InLocation(v interface{}, loc *time.Location) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return v
+	}
+
+	c := reflect.New(rv.Type()).Elem()
+	c.Set(rv)
+	setLocation(c, loc)
+
+	return c.Interface()
+}
+
+var (
+	datetimeType     = reflect.TypeOf(Datetime{})
+	nullDatetimeType = reflect.TypeOf(NullDatetime{})
+)
+
+// setLocation converts the datetimes of v in place.  Pointers and slices are copied first so the original is unchanged.
+func setLocation(v reflect.Value, loc *time.Location) {
+	if !v.CanSet() {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+
+		e := reflect.New(v.Type().Elem())
+		e.Elem().Set(v.Elem())
+		setLocation(e.Elem(), loc)
+		v.Set(e)
+	case reflect.Slice:
+		if k := v.Type().Elem().Kind(); v.IsNil() || (k != reflect.Struct && k != reflect.Ptr) {
+			return
+		}
+
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(s, v)
+		for i := 0; i < s.Len(); i++ {
+			setLocation(s.Index(i), loc)
+		}
+		v.Set(s)
+	case reflect.Struct:
+		switch v.Type() {
+		case datetimeType:
+			dt := v.Interface().(Datetime)
+			dt.Time = dt.Time.In(loc)
+			v.Set(reflect.ValueOf(dt))
+			return
+		case nullDatetimeType:
+			ndt := v.Interface().(NullDatetime)
+			if ndt.Valid {
+				ndt.Time.Time = ndt.Time.Time.In(loc)
+				v.Set(reflect.ValueOf(ndt))
+			}
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			setLocation(v.Field(i), loc)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+func TestLocationFromContext(t *testing.T) {
+	if _, ok := LocationFromContext(context.Background()); ok {
+		t.Error("Did not expect a location without ContextWithLocation")
+	}
+
+	loc := time.FixedZone("test", 60*60)
+	if actual, ok := LocationFromContext(ContextWithLocation(context.Background(), loc)); !ok || actual != loc {
+		t.Errorf("Expected %v, got %v", loc, actual)
+	}
+}
+
+func TestInLocation(t *testing.T) {
+	type child struct {
+		At Datetime `json:"at"`
+	}
+
+	type model struct {
+		Id        string       `json:"id"`
+		CreatedAt Datetime     `json:"createdAt"`
+		UpdatedAt NullDatetime `json:"updatedAt"`
+		DeletedAt NullDatetime `json:"deletedAt"`
+		Birthday  Date         `json:"birthday"`
+		Child     *child       `json:"child"`
+		Children  []child      `json:"children"`
+	}
+
+	utc := time.Date(2018, 1, 20, 20, 1, 0, 0, time.UTC)
+	loc := time.FixedZone("test", -5*60*60)
+	original := model{
+		Id:        "1",
+		CreatedAt: Datetime{Time: utc},
+		UpdatedAt: NullDatetime{Time: null.TimeFrom(utc)},
+		Birthday:  Date{Time: utc},
+		Child:     &child{At: Datetime{Time: utc}},
+		Children:  []child{{At: Datetime{Time: utc}}},
+	}
+
+	actual := InLocation(original, loc).(model)
+
+	for name, at := range map[string]time.Time{
+		"createdAt":   actual.CreatedAt.Time,
+		"updatedAt":   actual.UpdatedAt.Time.Time,
+		"child.at":    actual.Child.At.Time,
+		"children.at": actual.Children[0].At.Time,
+	} {
+		if at.Location() != loc || !at.Equal(utc) {
+			t.Errorf("Expected %s to be converted to the location, got %s", name, at)
+		}
+	}
+
+	if actual.DeletedAt.Valid || actual.Birthday.Time.Location() != time.UTC || actual.Id != "1" {
+		t.Errorf("Expected null datetimes, dates and other fields to be unchanged, got %+v", actual)
+	}
+
+	if original.CreatedAt.Time.Location() != time.UTC || original.Child.At.Time.Location() != time.UTC || original.Children[0].At.Time.Location() != time.UTC {
+		t.Error("Expected the original model to be unchanged")
+	}
+
+	if ptr := InLocation(&original, loc).(*model); ptr == &original || ptr.CreatedAt.Time.Location() != loc {
+		t.Error("Expected a converted copy of a pointer")
+	}
+
+	if InLocation(nil, loc) != nil {
+		t.Error("Expected nil to be returned unchanged")
+	}
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestStrictUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Value          json.Unmarshaler
+		Input          string
+		ExpectedResult string
+	}{
+		// tests that should fail
+		{"NullInt string", &NullInt{}, `"12"`, `json: cannot unmarshal "12" into Go value of type types.NullInt`},
+		{"NullInt object", &NullInt{}, `{"Int64": 1, "Valid": true}`, `json: cannot unmarshal {"Int64": 1, "Valid": true} into Go value of type types.NullInt`},
+		{"NullInt fraction", &NullInt{}, `1.5`, `json: cannot unmarshal number 1.5 into Go value of type int64`},
+		{"NullFloat bool", &NullFloat{}, `true`, `json: cannot unmarshal true into Go value of type types.NullFloat`},
+		{"NullFloat array", &NullFloat{}, `[1]`, `json: cannot unmarshal [1] into Go value of type types.NullFloat`},
+		{"NullBool number", &NullBool{}, `1`, `json: cannot unmarshal 1 into Go value of type types.NullBool`},
+		{"NullBool string", &NullBool{}, `"true"`, `json: cannot unmarshal "true" into Go value of type types.NullBool`},
+		{"NullUUID invalid", &NullUUID{}, `"123"`, `json: cannot unmarshal "123" into Go value of type types.NullUUID`},
+		{"NullUUID number", &NullUUID{}, `123`, `json: cannot unmarshal 123 into Go value of type types.NullUUID`},
+		{"Unmarshal error", &NullInt{}, ``, `unexpected end of JSON input`},
+		// tests that should succeed
+		{"NullInt number", &NullInt{}, `12`, "12"},
+		{"NullInt null", &NullInt{}, `null`, fmt.Sprintf("%v", nil)},
+		{"NullFloat number", &NullFloat{}, `1.5`, "1.5"},
+		{"NullFloat null", &NullFloat{}, `null`, fmt.Sprintf("%v", nil)},
+		{"NullBool false", &NullBool{}, `false`, "false"},
+		{"NullBool null", &NullBool{}, `null`, fmt.Sprintf("%v", nil)},
+		{"NullUUID uuid", &NullUUID{}, `"f47ac10b-58cc-4372-a567-0e02b2c3d479"`, "f47ac10b-58cc-4372-a567-0e02b2c3d479"},
+		{"NullUUID null", &NullUUID{}, `null`, fmt.Sprintf("%v", nil)},
+		{"NullJSON object", &NullJSON{}, `{"a": [1, 2]}`, `{"a": [1, 2]}`},
+		{"NullJSON null", &NullJSON{}, `null`, fmt.Sprintf("%v", nil)},
+	}
+
+	for _, test := range tests {
+		err := test.Value.UnmarshalJSON([]byte(test.Input))
+
+		if err != nil && err.Error() != test.ExpectedResult {
+			t.Errorf(`%s expected "%s", got "%v"`, test.Name, test.ExpectedResult, err)
+		} else if val, _ := test.Value.(driver.Valuer).Value(); err == nil && fmt.Sprintf("%v", val) != test.ExpectedResult {
+			t.Errorf(`%s expected "%s", got "%v"`, test.Name, test.ExpectedResult, val)
+		}
+	}
+}
+
+func TestNullMarshalJSON(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Value    json.Marshaler
+		Expected string
+	}{
+		{"NullInt", NewNullInt(12, true), "12"},
+		{"Null NullInt", NewNullInt(12, false), "null"},
+		{"NullFloat", NewNullFloat(1.5, true), "1.5"},
+		{"Null NullFloat", NewNullFloat(1.5, false), "null"},
+		{"NullBool", NewNullBool(false, true), "false"},
+		{"Null NullBool", NewNullBool(true, false), "null"},
+		{"NullUUID", NewNullUUID("f47ac10b-58cc-4372-a567-0e02b2c3d479", true), `"f47ac10b-58cc-4372-a567-0e02b2c3d479"`},
+		{"Null NullUUID", NewNullUUID("", false), "null"},
+		{"NullJSON", NewNullJSON([]byte(`{"a":1}`), true), `{"a":1}`},
+		{"Null NullJSON", NewNullJSON(nil, false), "null"},
+	}
+
+	for _, test := range tests {
+		actual, err := json.Marshal(test.Value)
+		if err != nil || string(actual) != test.Expected {
+			t.Errorf(`%s expected "%s", got "%s", %v`, test.Name, test.Expected, actual, err)
+		}
+	}
+}
+
+func TestNullJSON_Scan(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    interface{}
+		Expected NullJSON
+	}{
+		{"Scan bytes", []byte(`[1]`), NewNullJSON([]byte(`[1]`), true)},
+		{"Scan string", `{}`, NewNullJSON([]byte(`{}`), true)},
+		{"Scan null", nil, NullJSON{}},
+	}
+
+	for _, test := range tests {
+		var j NullJSON
+		if err := j.Scan(test.Input); err != nil || j.Valid != test.Expected.Valid || string(j.JSON) != string(test.Expected.JSON) {
+			t.Errorf("%s expected %v, got %v, %v", test.Name, test.Expected, j, err)
+		}
+	}
+
+	var j NullJSON
+	if err := j.Scan(12); err == nil {
+		t.Error("Expected error scanning an int and got none")
+	}
+}
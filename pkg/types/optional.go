@@ -0,0 +1,124 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// The states of an Optional
+const (
+	// OPTIONAL_ABSENT is a member that wasn't in the request and should be left untouched
+	OPTIONAL_ABSENT = iota
+	// OPTIONAL_NULL is a member that was set to null and should be cleared
+	OPTIONAL_NULL
+	// OPTIONAL_SET is a member that was set to a value
+	OPTIONAL_SET
+)
+
+// Optional tells apart a JSON member that is absent, null or set to a value.  The value is kept as raw JSON and
+// decoded with Decode.  Use it for struct members of a patch request, the zero value is absent.
+type Optional struct {
+	Raw     json.RawMessage
+	Present bool
+}
+
+// NewOptional creates an Optional that is present with the encoded value
+func NewOptional(v interface{}) (Optional, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return Optional{}, err
+	}
+
+	return Optional{Raw: raw, Present: true}, nil
+}
+
+// UnmarshalJSON is only called for members that are in the JSON, including those that are null
+func (o *Optional) UnmarshalJSON(data []byte) error {
+	o.Raw = append(json.RawMessage(nil), data...)
+	o.Present = true
+
+	return nil
+}
+
+// MarshalJSON outputs the raw value, or null when it's absent
+func (o Optional) MarshalJSON() ([]byte, error) {
+	if o.State() != OPTIONAL_SET {
+		return []byte("null"), nil
+	}
+
+	return o.Raw, nil
+}
+
+// State returns OPTIONAL_ABSENT, OPTIONAL_NULL or OPTIONAL_SET
+func (o Optional) State() int {
+	switch {
+	case !o.Present:
+		return OPTIONAL_ABSENT
+	case len(o.Raw) == 0 || bytes.Equal(bytes.TrimSpace(o.Raw), []byte("null")):
+		return OPTIONAL_NULL
+	}
+
+	return OPTIONAL_SET
+}
+
+// IsAbsent returns true when the member wasn't in the JSON
+func (o Optional) IsAbsent() bool {
+	return o.State() == OPTIONAL_ABSENT
+}
+
+// IsNull returns true when the member was null
+func (o Optional) IsNull() bool {
+	return o.State() == OPTIONAL_NULL
+}
+
+// IsSet returns true when the member had a value
+func (o Optional) IsSet() bool {
+	return o.State() == OPTIONAL_SET
+}
+
+// Decode unmarshals the value into v.  An absent value leaves v untouched.
+func (o Optional) Decode(v interface{}) error {
+	if !o.Present {
+		return nil
+	}
+
+	return json.Unmarshal(o.Raw, v)
+}
+
+// Patch holds the members of a request body by struct member name.  Members that weren't in the request are absent.
+type Patch map[string]Optional
+
+// Get returns the member, which is absent when it wasn't in the request
+func (p Patch) Get(field string) Optional {
+	return p[field]
+}
+
+// Fields returns the names of the members that were set or cleared, sorted by name.  They can be passed to
+// db.FieldsUpdater.UpdateFields.
+func (p Patch) Fields() []string {
+	return p.filter(func(o Optional) bool { return !o.IsAbsent() })
+}
+
+// Set returns the names of the members that were set to a value, sorted by name
+func (p Patch) Set() []string {
+	return p.filter(Optional.IsSet)
+}
+
+// Cleared returns the names of the members that were set to null, sorted by name
+func (p Patch) Cleared() []string {
+	return p.filter(Optional.IsNull)
+}
+
+func (p Patch) filter(fn func(o Optional) bool) []string {
+	fields := make([]string, 0, len(p))
+	for field, o := range p {
+		if fn(o) {
+			fields = append(fields, field)
+		}
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOptional_UnmarshalJSON(t *testing.T) {
+	type request struct {
+		Name     Optional `json:"name"`
+		Nickname Optional `json:"nickname"`
+		Age      Optional `json:"age"`
+	}
+
+	var r request
+	if err := json.Unmarshal([]byte(`{"name":"Ann","nickname":null}`), &r); err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		value    Optional
+		state    int
+		isSet    bool
+		isNull   bool
+		isAbsent bool
+	}{
+		{"A value is set", r.Name, OPTIONAL_SET, true, false, false},
+		{"A null is cleared", r.Nickname, OPTIONAL_NULL, false, true, false},
+		{"A missing member is absent", r.Age, OPTIONAL_ABSENT, false, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.value.State() != test.state || test.value.IsSet() != test.isSet || test.value.IsNull() != test.isNull || test.value.IsAbsent() != test.isAbsent {
+				t.Errorf("Expected state %d, got %d", test.state, test.value.State())
+			}
+		})
+	}
+
+	var name NullString
+	if err := r.Name.Decode(&name); err != nil || name.String.String != "Ann" {
+		t.Errorf("Expected Ann to be decoded, got %v, %v", name, err)
+	}
+
+	var nickname NullString
+	if err := r.Nickname.Decode(&nickname); err != nil || nickname.Valid {
+		t.Errorf("Expected null to be decoded, got %v, %v", nickname, err)
+	}
+
+	age := 30
+	if err := r.Age.Decode(&age); err != nil || age != 30 {
+		t.Errorf("Expected an absent value to leave the value untouched, got %d, %v", age, err)
+	}
+}
+
+func TestOptional_MarshalJSON(t *testing.T) {
+	set, _ := NewOptional("Ann")
+	out, _ := json.Marshal(struct {
+		Name   Optional `json:"name"`
+		Absent Optional `json:"absent"`
+	}{Name: set})
+
+	if string(out) != `{"name":"Ann","absent":null}` {
+		t.Errorf("Unexpected output %s", out)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	set, _ := NewOptional(1)
+	patch := Patch{
+		"Name":     set,
+		"Nickname": Optional{Raw: []byte("null"), Present: true},
+		"Age":      Optional{},
+	}
+
+	if !reflect.DeepEqual(patch.Fields(), []string{"Name", "Nickname"}) {
+		t.Errorf("Expected [Name Nickname], got %v", patch.Fields())
+	}
+
+	if !reflect.DeepEqual(patch.Set(), []string{"Name"}) {
+		t.Errorf("Expected [Name], got %v", patch.Set())
+	}
+
+	if !reflect.DeepEqual(patch.Cleared(), []string{"Nickname"}) {
+		t.Errorf("Expected [Nickname], got %v", patch.Cleared())
+	}
+
+	if !patch.Get("Missing").IsAbsent() {
+		t.Error("Expected a missing member to be absent")
+	}
+}
@@ -0,0 +1,21 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// unmarshalStrict returns an error unless the JSON in data is null or one of the allowed kinds, e.g. a NullInt
+// accepts a number but not a string, an object or an array
+func unmarshalStrict(data []byte, typeName string, allowed func(v interface{}) bool) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v != nil && !allowed(v) {
+		return fmt.Errorf("json: cannot unmarshal %v into Go value of type types.%s", string(data), typeName)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"regexp"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// NullUUID is a null.String that only holds a UUID in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+type NullUUID struct {
+	null.String
+}
+
+// NewNullUUID creates a new null uuid
+func NewNullUUID(s string, valid bool) NullUUID {
+	return NullUUID{String: null.NewString(s, valid)}
+}
+
+// UnmarshalJSON unmarshals data into a NullUUID if and only if data is a UUID string or null
+func (u *NullUUID) UnmarshalJSON(data []byte) error {
+	if err := unmarshalStrict(data, "NullUUID", func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && uuidRegex.MatchString(s)
+	}); err != nil {
+		return err
+	}
+
+	return u.String.UnmarshalJSON(data)
+}
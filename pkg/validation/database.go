@@ -0,0 +1,239 @@
+package validation
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/db"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	// RULE_UNIQUE_IN fails when another row already has the value, e.g. `validate:"unique_in=users.email"`.  The row
+	// with the same id as the model is ignored so the model can be updated without changing the value, and so are the
+	// rows that the model's db.SOFT_DELETE_TAG column marks as deleted.  It isn't named unique so it doesn't replace the
+	// rule of go-playground that checks the elements of a slice or map are unique.
+	RULE_UNIQUE_IN = "unique_in"
+	// RULE_EXISTS fails when no row has the value, e.g. `validate:"exists=schools.id"`.  The deleted column of a soft
+	// deletable table follows the column, e.g. `validate:"exists=schools.id deleted_at"`, so deleted rows don't count.
+	RULE_EXISTS = "exists"
+)
+
+// QueryError is returned by Struct and Var instead of the field errors when a database rule could not query the table
+type QueryError struct {
+	Rule string
+	Err  error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s rule failed to query the database: %s", e.Rule, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// queryErrorKey is the context key of the *QueryError of a validation
+type queryErrorKey struct{}
+
+// withQueryError returns the context that the database rules report the first failed query to
+func withQueryError(ctx context.Context) (context.Context, **QueryError) {
+	queryErr := new(*QueryError)
+
+	return context.WithValue(ctx, queryErrorKey{}, queryErr), queryErr
+}
+
+// reportQueryError keeps the first failed query of the validation
+func reportQueryError(ctx context.Context, rule string, err error) {
+	if queryErr, ok := ctx.Value(queryErrorKey{}).(**QueryError); ok && *queryErr == nil {
+		*queryErr = &QueryError{Rule: rule, Err: err}
+	}
+}
+
+// RegisterDatabaseRules adds the unique_in and exists rules which query the tables through the session.  Empty and null
+// values are valid so they can be combined with required.  The queries use the context of StructContext and VarContext
+// so they end with the request.  A failed query makes the validation return a *QueryError rather than an invalid field.
+func (pgv *PlaygroundValidator) RegisterDatabaseRules(sess dbr.SessionRunner) error {
+	return pgv.RegisterDatabaseRulesWithTimeout(sess, 0)
+}
+
+// RegisterDatabaseRulesWithTimeout adds the database rules where every query is cancelled once the timeout has passed,
+// the same as the queries of db.NewRepositoryWithTimeout
+func (pgv *PlaygroundValidator) RegisterDatabaseRulesWithTimeout(sess dbr.SessionRunner, timeout time.Duration) error {
+	v, err := pgv.validate()
+	if err != nil {
+		return err
+	}
+
+	if err := v.RegisterValidationCtx(RULE_UNIQUE_IN, func(ctx context.Context, fl validator.FieldLevel) bool {
+		value, ok := getDatabaseValue(fl)
+		if !ok {
+			return true
+		}
+
+		table, column, deleted := parseTableColumn(fl.Param())
+		query := sess.Select("COUNT(*)").From(table).Where(dbr.Eq(column, value))
+		if id, ok := getId(fl.Parent()); ok {
+			query = query.Where(dbr.Neq("id", id))
+		}
+
+		// the table is the table of the model, the same as for the id, so its deleted column applies
+		if deleted == "" && reflect.Indirect(fl.Parent()).Kind() == reflect.Struct {
+			deleted, _ = db.GetSoftDeleteColumn(reflect.Indirect(fl.Parent()).Interface())
+		}
+
+		if deleted != "" {
+			query = query.Where(dbr.Eq(deleted, nil))
+		}
+
+		count, err := loadCount(ctx, query, timeout)
+		if err != nil {
+			reportQueryError(ctx, RULE_UNIQUE_IN, err)
+			return true
+		}
+
+		return count == 0
+	}); err != nil {
+		return err
+	}
+
+	return v.RegisterValidationCtx(RULE_EXISTS, func(ctx context.Context, fl validator.FieldLevel) bool {
+		value, ok := getDatabaseValue(fl)
+		if !ok {
+			return true
+		}
+
+		table, column, deleted := parseTableColumn(fl.Param())
+		query := sess.Select("COUNT(*)").From(table).Where(dbr.Eq(column, value))
+		if deleted != "" {
+			query = query.Where(dbr.Eq(deleted, nil))
+		}
+
+		count, err := loadCount(ctx, query, timeout)
+		if err != nil {
+			reportQueryError(ctx, RULE_EXISTS, err)
+			return true
+		}
+
+		return count > 0
+	})
+}
+
+// RegisterDatabaseRules adds the unique_in and exists rules to the Singleton validator
+func RegisterDatabaseRules(sess dbr.SessionRunner) error {
+	pgv, ok := (*Singleton()).(*PlaygroundValidator)
+	if !ok {
+		return fmt.Errorf("the validator does not support database rules")
+	}
+
+	return pgv.RegisterDatabaseRules(sess)
+}
+
+// RegisterDatabaseRulesWithTimeout adds the database rules with a query timeout to the Singleton validator
+func RegisterDatabaseRulesWithTimeout(sess dbr.SessionRunner, timeout time.Duration) error {
+	pgv, ok := (*Singleton()).(*PlaygroundValidator)
+	if !ok {
+		return fmt.Errorf("the validator does not support database rules")
+	}
+
+	return pgv.RegisterDatabaseRulesWithTimeout(sess, timeout)
+}
+
+// getDatabaseValue returns the value of the field to query for.  False is returned for empty and null values.
+func getDatabaseValue(fl validator.FieldLevel) (interface{}, bool) {
+	// custom types such as types.NullString have already been replaced by their value, 0 when null, so the original
+	// struct member is used instead
+	field := fl.Field()
+	if parent := reflect.Indirect(fl.Parent()); parent.Kind() == reflect.Struct {
+		if original := parent.FieldByName(fl.StructFieldName()); original.IsValid() {
+			field = original
+		}
+	}
+
+	if valuer, ok := field.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil || value == nil || value == "" {
+			return nil, false
+		}
+
+		return value, true
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil, false
+		}
+
+		field = field.Elem()
+	}
+
+	if field.Kind() == reflect.String && field.String() == "" {
+		return nil, false
+	}
+
+	return field.Interface(), true
+}
+
+// getId returns the id of the model so it can be excluded from the unique_in rule.  False is returned for a new model.
+func getId(parent reflect.Value) (interface{}, bool) {
+	parent = reflect.Indirect(parent)
+	if parent.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	t := parent.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("db"), ",")[0] != "id" && t.Field(i).Name != "Id" {
+			continue
+		}
+
+		id := parent.Field(i)
+		if id.Kind() == reflect.String && id.String() == "" {
+			return nil, false
+		}
+
+		return id.Interface(), true
+	}
+
+	return nil, false
+}
+
+// parseTableColumn splits the parameter of a database rule into the table, the column and the optional deleted column.
+// It panics when the parameter is not `table.column` or `table.column deleted_column` the same way the other rules
+// panic when the tag is invalid.
+func parseTableColumn(param string) (string, string, string) {
+	fields := strings.Fields(param)
+	if len(fields) == 0 || len(fields) > 2 {
+		panic(fmt.Sprintf("database validation tags require a table.column parameter, got: %s", param))
+	}
+
+	parts := strings.Split(fields[0], ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		panic(fmt.Sprintf("database validation tags require a table.column parameter, got: %s", param))
+	}
+
+	deleted := ""
+	if len(fields) == 2 {
+		deleted = fields[1]
+	}
+
+	return parts[0], parts[1], deleted
+}
+
+func loadCount(ctx context.Context, query *dbr.SelectStmt, timeout time.Duration) (int, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	count := 0
+	err := query.LoadOneContext(ctx, &count)
+
+	return count, err
+}
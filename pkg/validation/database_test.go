@@ -0,0 +1,178 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gocraft/dbr"
+	"github.com/gocraft/dbr/dialect"
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+type databaseModel struct {
+	Id       string           `json:"id" db:"id"`
+	Email    types.NullString `json:"email" db:"email" validate:"unique_in=users.email"`
+	SchoolId string           `json:"schoolId" db:"school_id" validate:"omitempty,exists=schools.id"`
+}
+
+type softDeleteDatabaseModel struct {
+	Id        string             `json:"id" db:"id"`
+	Email     types.NullString   `json:"email" db:"email" validate:"unique_in=users.email"`
+	SchoolId  string             `json:"schoolId" db:"school_id" validate:"omitempty,exists=schools.id deleted_at"`
+	DeletedAt types.NullDatetime `json:"deletedAt" db:"deleted_at" softdelete:"true"`
+}
+
+func TestPlaygroundValidator_RegisterDatabaseRules(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+	if err := pgv.RegisterDatabaseRules(sess); err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	countRows := func(count int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"count"}).AddRow(count)
+	}
+
+	t.Run("Pass when the value is unique and the reference exists", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE ("email" = 'a@b.c')`) + "$").WillReturnRows(countRows(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM schools WHERE ("id" = '1')`)).WillReturnRows(countRows(1))
+
+		err := pgv.Struct(databaseModel{Email: types.NewNullString("a@b.c", true), SchoolId: "1"})
+		if err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Report a field error for a duplicate and a missing reference", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE ("email" = 'a@b.c')`)).WillReturnRows(countRows(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM schools WHERE ("id" = '2')`)).WillReturnRows(countRows(0))
+
+		err := pgv.Struct(databaseModel{Email: types.NewNullString("a@b.c", true), SchoolId: "2"})
+
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Fatalf("Expected 2 field errors, got %v", err)
+		}
+
+		if errs[0].Field() != "email" || errs[0].Rule() != RULE_UNIQUE_IN || errs[1].Field() != "schoolId" || errs[1].Rule() != RULE_EXISTS {
+			t.Errorf("Expected unique to fail for email and exists for schoolId, got %v", err)
+		}
+
+		if msg := Translate(errs[0], GetTranslator("en")); msg != "email is already in use" {
+			t.Errorf("Expected a translated message, got %s", msg)
+		}
+	})
+
+	t.Run("Exclude the current record", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE ("email" = 'a@b.c') AND ("id" != '123')`)).WillReturnRows(countRows(0))
+
+		if err := pgv.Struct(databaseModel{Id: "123", Email: types.NewNullString("a@b.c", true)}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Skip null values", func(t *testing.T) {
+		if err := pgv.Struct(databaseModel{Email: types.NewNullString("", false)}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Panic for an invalid parameter", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected a panic for an invalid parameter")
+			}
+		}()
+
+		pgv.Struct(struct {
+			Email string `validate:"unique_in=users"`
+		}{"a@b.c"})
+	})
+
+	t.Run("Return an error when the query fails", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users`)).WillReturnError(errors.New("connection refused"))
+
+		err := pgv.Struct(databaseModel{Email: types.NewNullString("a@b.c", true)})
+
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || queryErr.Rule != RULE_UNIQUE_IN {
+			t.Errorf("Expected a *QueryError, got %v", err)
+		}
+
+		var errs Errors
+		if errors.As(err, &errs) {
+			t.Errorf("Did not expect field errors, got %v", errs)
+		}
+	})
+
+	t.Run("Ignore the soft deleted rows", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users WHERE ("email" = 'a@b.c') AND ("deleted_at" IS NULL)`)).WillReturnRows(countRows(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM schools WHERE ("id" = '1') AND ("deleted_at" IS NULL)`)).WillReturnRows(countRows(1))
+
+		if err := pgv.Struct(softDeleteDatabaseModel{Email: types.NewNullString("a@b.c", true), SchoolId: "1"}); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Query with the context of the validation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := pgv.StructContext(ctx, databaseModel{Email: types.NewNullString("a@b.c", true)})
+
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the query to be cancelled, got %v", err)
+		}
+	})
+
+	t.Run("Keep the unique rule of go-playground", func(t *testing.T) {
+		err := pgv.Struct(struct {
+			Tags []string `json:"tags" validate:"unique"`
+		}{[]string{"a", "a"}})
+
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Rule() != "unique" {
+			t.Fatalf("Expected the unique rule to fail for tags, got %v", err)
+		}
+
+		if msg := Translate(errs[0], GetTranslator("en")); msg == "tags is already in use" {
+			t.Errorf("Expected the message of the unique rule of go-playground, got %s", msg)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlaygroundValidator_RegisterDatabaseRulesWithTimeout(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	conn := &dbr.Connection{DB: db, Dialect: dialect.PostgreSQL, EventReceiver: &dbr.NullEventReceiver{}}
+	sess := conn.NewSession(nil)
+
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+	if err := pgv.RegisterDatabaseRulesWithTimeout(sess, time.Millisecond); err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM users`)).
+		WillDelayFor(100 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	err := pgv.Struct(databaseModel{Email: types.NewNullString("a@b.c", true)})
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Errorf("Expected the query to time out, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	// RULE_BEFORE fails unless the date is before another field or a date, e.g. `validate:"before=EndDate"`
+	RULE_BEFORE = "before"
+	// RULE_AFTER fails unless the date is after another field or a date, e.g. `validate:"after=2000-01-01"`
+	RULE_AFTER = "after"
+	// RULE_PAST fails unless the date is before the current time
+	RULE_PAST = "past"
+	// RULE_FUTURE fails unless the date is after the current time
+	RULE_FUTURE = "future"
+	// RULE_DATE_RANGE fails unless the date is within a duration of another field, a negative duration is a range
+	// before the field, e.g. `validate:"date_range=StartDate 30d"`
+	RULE_DATE_RANGE = "date_range"
+)
+
+// dateFormats are the formats a date parameter can be written in
+var dateFormats = []string{types.FORMAT_DATE, types.FORMAT_DATETIME_INPUT, time.RFC3339}
+
+// ValidateTime implements validator.CustomTypeFunc for the date types so they are validated as a time.Time.  A zero
+// time is returned for null values.
+func ValidateTime(field reflect.Value) interface{} {
+	switch t := field.Interface().(type) {
+	case types.Datetime:
+		return t.Time
+	case types.Date:
+		return t.Time
+	case types.NullDatetime:
+		if !t.Valid {
+			return time.Time{}
+		}
+		return t.Time.Time
+	case types.NullDate:
+		if !t.Valid {
+			return time.Time{}
+		}
+		return t.Time.Time
+	}
+
+	return nil
+}
+
+// registerDateRules adds the date rules to the validator.  Null and zero dates are valid so the rules can be combined
+// with required.
+func registerDateRules(v *validator.Validate) {
+	v.RegisterValidation(RULE_BEFORE, func(fl validator.FieldLevel) bool {
+		value, other, ok := getDates(fl, fl.Param())
+
+		return !ok || value.Before(other)
+	})
+
+	v.RegisterValidation(RULE_AFTER, func(fl validator.FieldLevel) bool {
+		value, other, ok := getDates(fl, fl.Param())
+
+		return !ok || value.After(other)
+	})
+
+	v.RegisterValidation(RULE_PAST, func(fl validator.FieldLevel) bool {
+		value, ok := toTime(fl.Field())
+
+		return !ok || value.Before(time.Now())
+	})
+
+	v.RegisterValidation(RULE_FUTURE, func(fl validator.FieldLevel) bool {
+		value, ok := toTime(fl.Field())
+
+		return !ok || value.After(time.Now())
+	})
+
+	v.RegisterValidation(RULE_DATE_RANGE, func(fl validator.FieldLevel) bool {
+		params := strings.Fields(fl.Param())
+		if len(params) != 2 {
+			panic(fmt.Sprintf("date_range validation tag requires a field and a duration, got: %s", fl.Param()))
+		}
+
+		d := parseDuration(params[1])
+		value, start, ok := getDates(fl, params[0])
+		if !ok {
+			return true
+		}
+
+		end := start.Add(d)
+		if d < 0 {
+			start, end = end, start
+		}
+
+		return !value.Before(start) && !value.After(end)
+	})
+}
+
+// getDates returns the date of the field and the date of the parameter.  False is returned when either of them is null.
+func getDates(fl validator.FieldLevel, param string) (time.Time, time.Time, bool) {
+	value, ok := toTime(fl.Field())
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	other, ok := resolveDate(fl.Parent(), param)
+
+	return value, other, ok
+}
+
+// resolveDate returns the date of the struct member or json field named param.  Otherwise the param is parsed as a
+// date and it panics when it isn't one.
+func resolveDate(parent reflect.Value, param string) (time.Time, bool) {
+	if parent = reflect.Indirect(parent); parent.Kind() == reflect.Struct {
+		if field := parent.FieldByName(param); field.IsValid() {
+			return toTime(field)
+		}
+
+		if f, ok := getStructFieldByJsonTag(parent, param); ok {
+			return toTime(parent.FieldByIndex(f.Index))
+		}
+	}
+
+	for _, format := range dateFormats {
+		if t, err := time.Parse(format, param); err == nil {
+			return t, true
+		}
+	}
+
+	panic(fmt.Sprintf("date validation tags require a field or a date parameter, got: %s", param))
+}
+
+// toTime returns the time of a time.Time or one of the date types.  False is returned for null and zero values.
+func toTime(field reflect.Value) (time.Time, bool) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return time.Time{}, false
+		}
+
+		field = field.Elem()
+	}
+
+	if !field.IsValid() || !field.CanInterface() {
+		return time.Time{}, false
+	}
+
+	t, ok := field.Interface().(time.Time)
+	if !ok {
+		t, ok = ValidateTime(field).(time.Time)
+	}
+
+	return t, ok && !t.IsZero()
+}
+
+// parseDuration parses a time.Duration which can also be written in days, e.g. "30d"
+func parseDuration(param string) time.Duration {
+	if days := strings.TrimSuffix(param, "d"); days != param {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Duration(n) * 24 * time.Hour
+		}
+	}
+
+	d, err := time.ParseDuration(param)
+	if err != nil {
+		panic(fmt.Sprintf("date_range duration was not valid, got: %s", param))
+	}
+
+	return d
+}
@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+	"gopkg.in/guregu/null.v3"
+)
+
+type dateModel struct {
+	StartDate types.Date         `json:"startDate" validate:"required,before=EndDate"`
+	EndDate   types.Date         `json:"endDate" validate:"required,date_range=startDate 30d"`
+	Birthday  types.NullDate     `json:"birthday" validate:"omitempty,past,after=1900-01-01"`
+	Expires   types.NullDatetime `json:"expires" validate:"omitempty,future"`
+	Created   types.Datetime     `json:"created" validate:"omitempty,lte"`
+}
+
+func date(s string) time.Time {
+	t, _ := time.Parse(types.FORMAT_DATE, s)
+
+	return t
+}
+
+func TestValidateTime(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"Datetime", types.Datetime{Time: now}, now},
+		{"Date", types.Date{Time: now}, now},
+		{"Valid NullDatetime", types.NullDatetime{Time: null.TimeFrom(now)}, now},
+		{"Null NullDatetime", types.NullDatetime{}, time.Time{}},
+		{"Valid NullDate", types.NullDate{Time: null.TimeFrom(now)}, now},
+		{"Null NullDate", types.NullDate{}, time.Time{}},
+		{"Not a date type", "2019-01-01", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := ValidateTime(reflect.ValueOf(test.value)); actual != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPlaygroundValidator_DateRules(t *testing.T) {
+	pgv := NewPlaygroundValidator()
+	valid := dateModel{
+		StartDate: types.Date{Time: date("2019-01-01")},
+		EndDate:   types.Date{Time: date("2019-01-15")},
+		Birthday:  types.NullDate{Time: null.TimeFrom(date("1990-05-05"))},
+		Expires:   types.NullDatetime{Time: null.TimeFrom(time.Now().Add(time.Hour))},
+		Created:   types.Datetime{Time: time.Now().Add(-time.Hour)},
+	}
+
+	t.Run("Pass for valid dates", func(t *testing.T) {
+		if err := pgv.Struct(valid); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	t.Run("Pass for null dates", func(t *testing.T) {
+		m := valid
+		m.Birthday = types.NullDate{}
+		m.Expires = types.NullDatetime{}
+		m.Created = types.Datetime{}
+
+		if err := pgv.Struct(m); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		modify func(m *dateModel)
+		field  string
+		rule   string
+	}{
+		{"Required fails for a zero date", func(m *dateModel) { m.StartDate = types.Date{} }, "startDate", "required"},
+		{"Before fails on the same date as the other field", func(m *dateModel) { m.StartDate.Time = date("2019-01-15") }, "startDate", RULE_BEFORE},
+		{"Date range fails outside of the duration", func(m *dateModel) { m.EndDate.Time = date("2019-03-01") }, "endDate", RULE_DATE_RANGE},
+		{"After fails before the date", func(m *dateModel) { m.Birthday.Time = null.TimeFrom(date("1899-12-31")) }, "birthday", RULE_AFTER},
+		{"Past fails for a future date", func(m *dateModel) { m.Birthday.Time = null.TimeFrom(time.Now().Add(time.Hour)) }, "birthday", RULE_PAST},
+		{"Future fails for a past date", func(m *dateModel) { m.Expires.Time = null.TimeFrom(time.Now().Add(-time.Hour)) }, "expires", RULE_FUTURE},
+		{"Library rules compare with the current time", func(m *dateModel) { m.Created.Time = time.Now().Add(time.Hour) }, "created", "lte"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := valid
+			test.modify(&m)
+
+			var errs Errors
+			if err := pgv.Struct(m); !errors.As(err, &errs) {
+				t.Fatalf("Expected validation errors, got %v", err)
+			}
+
+			if len(errs) != 1 || errs[0].Field() != test.field || errs[0].Rule() != test.rule {
+				t.Errorf("Expected %s to fail for %s, got %v", test.rule, test.field, errs)
+			}
+		})
+	}
+
+	t.Run("Panic for an invalid parameter", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected a panic for an invalid parameter")
+			}
+		}()
+
+		pgv.Struct(struct {
+			Date types.Date `validate:"before=tomorrow"`
+		}{types.Date{Time: time.Now()}})
+	})
+}
+
+func TestParseDuration(t *testing.T) {
+	if d := parseDuration("30d"); d != 30*24*time.Hour {
+		t.Errorf("Expected 30 days, got %s", d)
+	}
+
+	if d := parseDuration("-90m"); d != -90*time.Minute {
+		t.Errorf("Expected -90 minutes, got %s", d)
+	}
+}
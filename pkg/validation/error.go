@@ -1,13 +1,25 @@
 package validation
 
 import (
-	"errors"
 	"fmt"
 	"strings"
+
+	"gopkg.in/go-playground/validator.v9"
 )
 
 const ERROR_DELIMITER = " || "
 
+const (
+	// RULE_UNKNOWN is reported by DecodeRequest for a property that isn't part of the model
+	RULE_UNKNOWN = "unknown"
+	// RULE_READONLY is reported by DecodeRequest for a property that is not allowed to be set
+	RULE_READONLY = "readonly"
+	// RULE_TYPE is reported by DecodeRequest for a value that can't be decoded into the property
+	RULE_TYPE = "type"
+	// RULE_DUPLICATE is reported by DecodeRequest for a property that is repeated within an object
+	RULE_DUPLICATE = "duplicate"
+)
+
 type Error interface {
 	Field() string
 	Rule() string
@@ -15,9 +27,14 @@ type Error interface {
 }
 
 type FieldError struct {
-	field string
-	rule  string
-	value interface{}
+	field   string
+	rule    string
+	value   interface{}
+	message string
+	// fieldError is the error from the playground library that is used to translate the message
+	fieldError validator.FieldError
+	// unprefixed errors are joined by Errors.Error without the field, which the legacy decode errors didn't have
+	unprefixed bool
 }
 
 func (fe FieldError) Rule() string {
@@ -32,6 +49,24 @@ func (fe FieldError) Field() string {
 	return fe.field
 }
 
+// Param is the parameter of the rule, e.g. 3 for min=3
+func (fe FieldError) Param() string {
+	if fe.fieldError == nil {
+		return ""
+	}
+
+	return fe.fieldError.Param()
+}
+
+// Message is the human readable description of the error without the field
+func (fe FieldError) Message() string {
+	if fe.message != "" {
+		return fe.message
+	}
+
+	return fmt.Sprintf("Failed validation for %s with value %v", fe.rule, fe.value)
+}
+
 func newFieldError(field string, rule string, value interface{}) FieldError {
 	return FieldError{
 		field: field,
@@ -40,14 +75,36 @@ func newFieldError(field string, rule string, value interface{}) FieldError {
 	}
 }
 
-// ConsolidateValidationErrors will take all of the accumulated errors and combine them as a single error
-// separated by the delimiter
-func consolidateValidationErrors(errs []Error) error {
+// Errors is the list of errors for each field that failed validation.  It can be retrieved with errors.As to build a
+// structured response, Error() returns the legacy message.
+type Errors []Error
 
-	errStrings := make([]string, 0)
+// Error joins the message of every field with the delimiter, e.g. "name: Failed validation for min with value a"
+func (errs Errors) Error() string {
+	errStrings := make([]string, 0, len(errs))
 	for _, e := range errs {
-		errStrings = append(errStrings, fmt.Sprintf("%s: Failed validation for %s with value %v", e.Field(), e.Rule(), e.Value()))
+		if fe, ok := e.(FieldError); ok && fe.unprefixed {
+			errStrings = append(errStrings, GetMessage(e))
+			continue
+		}
+
+		errStrings = append(errStrings, fmt.Sprintf("%s: %s", e.Field(), GetMessage(e)))
+	}
+
+	return strings.Join(errStrings, ERROR_DELIMITER)
+}
+
+// GetMessage returns the message of the error when it has one or the generic validation failure message otherwise
+func GetMessage(e Error) string {
+	if m, ok := e.(interface{ Message() string }); ok {
+		return m.Message()
 	}
 
-	return errors.New(strings.Join(errStrings, ERROR_DELIMITER))
+	return newFieldError(e.Field(), e.Rule(), e.Value()).Message()
+}
+
+// ConsolidateValidationErrors will take all of the accumulated errors and combine them as a single error
+// separated by the delimiter
+func consolidateValidationErrors(errs []Error) error {
+	return Errors(errs)
 }
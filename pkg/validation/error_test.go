@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -41,3 +42,33 @@ func TestConsolidateValidationErrors(t *testing.T) {
 		}
 	})
 }
+
+func TestErrors(t *testing.T) {
+	errs := Errors{
+		newFieldError("name", "min", "a"),
+		FieldError{field: "age", rule: RULE_TYPE, value: "true", message: "Must be a number."},
+		MockValidationError{field: "id", rule: "uuid4", value: "1"},
+	}
+
+	expected := "name: Failed validation for min with value a || age: Must be a number. || id: Failed validation for uuid4 with value 1"
+	if errs.Error() != expected {
+		t.Errorf("Expected %s, got %s", expected, errs.Error())
+	}
+
+	var target Errors
+	if !errors.As(consolidateValidationErrors(errs), &target) || len(target) != 3 {
+		t.Error("Expected the consolidated error to contain the field errors")
+	}
+
+	t.Run("Keep the legacy message of a decode error", func(t *testing.T) {
+		var model struct {
+			Age int `json:"age"`
+		}
+
+		err := DecodeRequest([]byte(`{"age":"a"}`), []string{}, &model)
+		expected := "json: cannot unmarshal string into Go value of type int"
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected %s, got %v", expected, err)
+		}
+	})
+}
@@ -0,0 +1,539 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+const (
+	// MERGE_PATCH_CONTENT_TYPE is the media type of a JSON Merge Patch (RFC 7396)
+	MERGE_PATCH_CONTENT_TYPE = "application/merge-patch+json"
+	// JSON_PATCH_CONTENT_TYPE is the media type of a JSON Patch (RFC 6902)
+	JSON_PATCH_CONTENT_TYPE = "application/json-patch+json"
+)
+
+// ErrPatchTestFailed is returned by DecodeJSONPatch when a test operation doesn't match the object
+var ErrPatchTestFailed = errors.New("json patch test operation failed")
+
+// DecodeMergePatch applies a JSON Merge Patch to the object.  Nested objects are merged and null removes a member, or
+// clears it at the top level.  The properties of the patch are checked against validFields the same way as
+// DecodePatch and the changed top level members are then decoded into the object.
+func DecodeMergePatch(body []byte, validFields []string, objPtr interface{}) (types.Patch, error) {
+	rv := checkValidFields(validFields, objPtr)
+
+	var patch map[string]interface{}
+	if err := unmarshalNumber(body, &patch); err != nil {
+		return nil, err
+	}
+
+	if err := checkDuplicateKeys(body); err != nil {
+		return nil, err
+	}
+
+	if patch == nil {
+		return nil, errors.New("a merge patch must be an object")
+	}
+
+	// only the properties of the patch are checked, the rest of the merged members are the current values
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+
+	if errs := checkProperties("", values, rv.Elem().Type(), rv.Elem(), validFields); len(errs) > 0 {
+		return nil, sortErrors(errs)
+	}
+
+	doc, err := getDocument(objPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]interface{}, len(patch))
+	for key, value := range patch {
+		changes[key] = mergePatch(doc[key], value)
+	}
+
+	return decodeChanges(changes, validFields, rv)
+}
+
+// DecodeJSONPatch applies the operations of a JSON Patch to the object.  The paths the operations change have to be
+// within validFields, a path into a member that only has some settable members has to end at one of them.  The top
+// level members touched by the paths are then decoded into the object and a removed top level member is cleared.
+// ErrPatchTestFailed is returned when a test operation fails.
+func DecodeJSONPatch(body []byte, validFields []string, objPtr interface{}) (types.Patch, error) {
+	rv := checkValidFields(validFields, objPtr)
+
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, err
+	}
+
+	if err := checkDuplicateKeys(body); err != nil {
+		return nil, err
+	}
+
+	doc, err := getDocument(objPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	var node interface{} = doc
+	changes := make(map[string]interface{})
+	errs := make(Errors, 0)
+	for i, op := range ops {
+		var paths [][]string
+		if node, paths, err = applyOperation(node, op); err != nil {
+			return nil, fmt.Errorf("json patch operation %d: %w", i, err)
+		}
+
+		for _, path := range paths {
+			errs = append(errs, checkPath(path, rv.Elem().Type(), validFields)...)
+			changes[path[0]] = nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, sortErrors(errs)
+	}
+
+	doc = node.(map[string]interface{})
+	for key := range changes {
+		changes[key] = doc[key]
+	}
+
+	return decodeChanges(changes, validFields, rv)
+}
+
+// checkPath returns the field error of a path that isn't a member of the type or that can't be set
+func checkPath(tokens []string, t reflect.Type, validFields []string) Errors {
+	path := ""
+	for _, token := range tokens {
+		t = indirectType(t)
+		if path != "" {
+			path += "."
+		}
+		path += token
+
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+			continue
+		}
+
+		// the members of other values, e.g. types.NullJSON, are checked when they are decoded
+		if !decodesObject(t) {
+			return nil
+		}
+
+		m, ok := getJsonMembers(t)[token]
+		if !ok {
+			return Errors{FieldError{field: path, rule: RULE_UNKNOWN, message: "This property does not exist."}}
+		}
+
+		if len(validFields) > 0 {
+			if validFields, ok = getNestedFields(validFields, m.name); !ok {
+				return Errors{newReadonlyError(path, nil)}
+			}
+		}
+
+		t = m.typ
+	}
+
+	// the path replaces or removes a member, or an element, that only has some settable members
+	if len(validFields) > 0 {
+		return Errors{newReadonlyError(path, nil)}
+	}
+
+	return nil
+}
+
+// getDocument returns the JSON representation of the object that the patches are applied to
+func getDocument(objPtr interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(objPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	if err := unmarshalNumber(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// decodeChanges decodes the changed top level members into the object.  The valid fields were already checked against
+// the patch so the changes are only checked for properties that don't exist.
+func decodeChanges(changes map[string]interface{}, validFields []string, rv reflect.Value) (types.Patch, error) {
+	body, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+
+	if errs := checkProperties("", values, rv.Elem().Type(), rv.Elem(), nil); len(errs) > 0 {
+		return nil, sortErrors(errs)
+	}
+
+	return decodeMembers(values, validFields, rv.Elem())
+}
+
+// unmarshalNumber keeps numbers as json.Number so large integers aren't rounded
+func unmarshalNumber(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	return d.Decode(v)
+}
+
+// mergePatch implements the MergePatch function of RFC 7396
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+
+	return t
+}
+
+// applyOperation applies one operation of a JSON Patch to the document and returns the paths it changed
+func applyOperation(doc interface{}, op map[string]json.RawMessage) (interface{}, [][]string, error) {
+	var name string
+	if err := json.Unmarshal(op["op"], &name); err != nil {
+		return nil, nil, errors.New("op must be a string")
+	}
+
+	path, err := parsePointer(op["path"], "path")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the top level members are decoded into the object so only a test can refer to the whole document
+	if len(path) == 0 && name != "test" {
+		return nil, nil, errors.New("path must be a JSON pointer to a member unless the op is test")
+	}
+
+	var from []string
+	if name == "move" || name == "copy" {
+		if from, err = parsePointer(op["from"], "from"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var value interface{}
+	if name == "add" || name == "replace" || name == "test" {
+		raw, ok := op["value"]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s requires a value", name)
+		}
+
+		if err := unmarshalNumber(raw, &value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	changed := [][]string{path}
+	if name == "move" {
+		changed = [][]string{from, path}
+	} else if name == "test" {
+		changed = nil
+	}
+
+	doc, err = patchDocument(doc, name, path, from, value)
+
+	return doc, changed, err
+}
+
+// patchDocument applies the operation to the document
+func patchDocument(doc interface{}, name string, path []string, from []string, value interface{}) (interface{}, error) {
+	var err error
+	switch name {
+	case "add":
+		return patchPointer(doc, path, addValue(value))
+	case "remove":
+		return patchPointer(doc, path, removeValue)
+	case "replace":
+		if doc, err = patchPointer(doc, path, removeValue); err != nil {
+			return nil, err
+		}
+		return patchPointer(doc, path, addValue(value))
+	case "move":
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, errors.New("a value cannot be moved into itself")
+		}
+
+		v, err := getPointer(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc, err = patchPointer(doc, from, removeValue); err != nil {
+			return nil, err
+		}
+		return patchPointer(doc, path, addValue(v))
+	case "copy":
+		v, err := getPointer(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if v, err = copyValue(v); err != nil {
+			return nil, err
+		}
+		return patchPointer(doc, path, addValue(v))
+	case "test":
+		v, err := getPointer(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !jsonEqual(v, value) {
+			return nil, ErrPatchTestFailed
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("unknown op '%s'", name)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its reference tokens.  The empty pointer refers to the whole
+// document and has no tokens.
+func parsePointer(raw json.RawMessage, name string) ([]string, error) {
+	var pointer string
+	if err := json.Unmarshal(raw, &pointer); err != nil {
+		return nil, fmt.Errorf("%s must be a string", name)
+	}
+
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%s must be a JSON pointer, got '%s'", name, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+// getPointer returns the value the tokens refer to
+func getPointer(node interface{}, tokens []string) (interface{}, error) {
+	for _, t := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[t]
+			if !ok {
+				return nil, fmt.Errorf("member '%s' does not exist", t)
+			}
+			node = v
+		case []interface{}:
+			i, err := arrayIndex(t, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("member '%s' does not exist", t)
+		}
+	}
+
+	return node, nil
+}
+
+// patchPointer calls fn with the container of the last token and replaces it with the result
+func patchPointer(node interface{}, tokens []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("member '%s' does not exist", tokens[0])
+		}
+
+		c, err := patchPointer(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = c
+
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := patchPointer(n[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = c
+
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("member '%s' does not exist", tokens[0])
+}
+
+// addValue adds a member to an object or inserts an element into an array, `-` appends to the array
+func addValue(value interface{}) func(container interface{}, token string) (interface{}, error) {
+	return func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			if token == "-" {
+				return append(c, value), nil
+			}
+
+			i, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+
+			return c, nil
+		}
+
+		return nil, fmt.Errorf("member '%s' cannot be added to a value that isn't an object or an array", token)
+	}
+}
+
+// removeValue removes a member of an object or an element of an array
+func removeValue(container interface{}, token string) (interface{}, error) {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		if _, ok := c[token]; !ok {
+			return nil, fmt.Errorf("member '%s' does not exist", token)
+		}
+
+		delete(c, token)
+		return c, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(c[:i], c[i+1:]...), nil
+	}
+
+	return nil, fmt.Errorf("member '%s' does not exist", token)
+}
+
+// arrayIndex parses an array index that has to be between 0 and max
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("'%s' is not a valid array index", token)
+	}
+
+	return i, nil
+}
+
+// copyValue returns a deep copy so later operations don't change both values
+func copyValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var c interface{}
+	err = unmarshalNumber(data, &c)
+
+	return c, err
+}
+
+// jsonEqual compares two JSON values, numbers are equal when they have the same value
+func jsonEqual(a interface{}, b interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		data, _ := json.Marshal(v)
+		var n interface{}
+		unmarshalNumber(data, &n)
+
+		return n
+	}
+
+	return normalizedEqual(normalize(a), normalize(b))
+}
+
+// normalizedEqual compares two decoded JSON values.  Numbers are compared as exact fractions so large integers that a
+// float64 would round, e.g. 9007199254740993, are only equal to themselves.
+func normalizedEqual(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		x, xOk := new(big.Rat).SetString(a.String())
+		y, yOk := new(big.Rat).SetString(b.String())
+
+		return xOk && yOk && x.Cmp(y) == 0
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for key, value := range a {
+			if other, ok := b[key]; !ok || !normalizedEqual(value, other) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !normalizedEqual(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,236 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+type patchAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip"`
+}
+
+type patchModel struct {
+	Id         string           `json:"id"`
+	FirstName  types.NullString `json:"firstName"`
+	MiddleName types.NullString `json:"middleName"`
+	Tags       []string         `json:"tags"`
+	Settings   types.NullJSON   `json:"settings"`
+	Address    patchAddress     `json:"address"`
+	Addresses  []patchAddress   `json:"addresses"`
+}
+
+var patchFields = []string{"FirstName", "MiddleName", "Tags", "Settings"}
+
+var nestedPatchFields = []string{"FirstName", "MiddleName", "Tags", "Settings", "Address.Zip", "Addresses.Zip"}
+
+func newPatchModel() *patchModel {
+	return &patchModel{
+		Id:         "1",
+		FirstName:  types.NewNullString("Ann", true),
+		MiddleName: types.NewNullString("Marie", true),
+		Tags:       []string{"a", "b"},
+		Settings:   types.NewNullJSON([]byte(`{"theme":"dark","lang":"en"}`), true),
+		Address:    patchAddress{Street: "Main", Zip: "1"},
+		Addresses:  []patchAddress{{Street: "Oak", Zip: "1"}},
+	}
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	t.Run("Merge the patch into the object", func(t *testing.T) {
+		m := newPatchModel()
+		patch, err := DecodeMergePatch([]byte(`{"firstName":"Anne","middleName":null,"settings":{"lang":null,"size":12}}`), patchFields, m)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if !reflect.DeepEqual(patch.Fields(), []string{"FirstName", "MiddleName", "Settings"}) {
+			t.Errorf("Expected the top level members of the patch, got %v", patch.Fields())
+		}
+
+		if m.FirstName.String.String != "Anne" || m.MiddleName.Valid || string(m.Settings.JSON) != `{"size":12,"theme":"dark"}` {
+			t.Errorf("Expected the patch to be merged, got %+v %s", m, m.Settings.JSON)
+		}
+
+		if !reflect.DeepEqual(m.Tags, []string{"a", "b"}) {
+			t.Errorf("Expected tags to be untouched, got %v", m.Tags)
+		}
+	})
+
+	t.Run("Enforce the valid fields", func(t *testing.T) {
+		var errs Errors
+		_, err := DecodeMergePatch([]byte(`{"id":"2"}`), patchFields, newPatchModel())
+		if !errors.As(err, &errs) || errs[0].Field() != "id" || errs[0].Rule() != RULE_READONLY {
+			t.Errorf("Expected a readonly rule for id, got %v", err)
+		}
+	})
+
+	t.Run("Only check the valid fields against the properties of the patch", func(t *testing.T) {
+		m := newPatchModel()
+		validFields := []string{"Address.Zip", "Addresses.Zip"}
+		if _, err := DecodeMergePatch([]byte(`{"address":{"zip":"2"},"addresses":[{"zip":"3"}]}`), validFields, m); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if m.Address != (patchAddress{Street: "Main", Zip: "2"}) || m.Addresses[0] != (patchAddress{Street: "Oak", Zip: "3"}) {
+			t.Errorf("Expected only the zips to change, got %+v %+v", m.Address, m.Addresses)
+		}
+
+		var errs Errors
+		_, err := DecodeMergePatch([]byte(`{"address":null,"addresses":[]}`), validFields, newPatchModel())
+		if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Rule() != RULE_READONLY || errs[1].Rule() != RULE_READONLY {
+			t.Errorf("Expected address and addresses to be readonly, got %v", err)
+		}
+	})
+
+	t.Run("Reject a patch that isn't an object", func(t *testing.T) {
+		if _, err := DecodeMergePatch([]byte(`null`), patchFields, newPatchModel()); err == nil {
+			t.Error("Expected error and got none")
+		}
+	})
+}
+
+func TestDecodeJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		fields   []string
+		expected func(m *patchModel) bool
+	}{
+		{
+			"Replace and remove members",
+			`[{"op":"replace","path":"/firstName","value":"Anne"},{"op":"remove","path":"/middleName"}]`,
+			[]string{"FirstName", "MiddleName"},
+			func(m *patchModel) bool { return m.FirstName.String.String == "Anne" && !m.MiddleName.Valid },
+		},
+		{
+			"Add to, insert into and remove from an array",
+			`[{"op":"add","path":"/tags/-","value":"c"},{"op":"add","path":"/tags/0","value":"z"},{"op":"remove","path":"/tags/1"}]`,
+			[]string{"Tags"},
+			func(m *patchModel) bool { return reflect.DeepEqual(m.Tags, []string{"z", "b", "c"}) },
+		},
+		{
+			"Patch a nested member after a passing test",
+			`[{"op":"test","path":"/settings/theme","value":"dark"},{"op":"add","path":"/settings/size","value":12}]`,
+			[]string{"Settings"},
+			func(m *patchModel) bool { return string(m.Settings.JSON) == `{"lang":"en","size":12,"theme":"dark"}` },
+		},
+		{
+			"Move and copy members",
+			`[{"op":"copy","from":"/firstName","path":"/middleName"},{"op":"move","from":"/settings/lang","path":"/settings/locale"},{"op":"add","path":"/settings/~1a~0b","value":1}]`,
+			[]string{"MiddleName", "Settings"},
+			func(m *patchModel) bool {
+				return m.MiddleName.String.String == "Ann" && string(m.Settings.JSON) == `{"/a~b":1,"locale":"en","theme":"dark"}`
+			},
+		},
+		{
+			"Replace an allowed nested member",
+			`[{"op":"replace","path":"/address/zip","value":"2"},{"op":"replace","path":"/addresses/0/zip","value":"3"}]`,
+			[]string{"Address", "Addresses"},
+			func(m *patchModel) bool {
+				return m.Address == patchAddress{Street: "Main", Zip: "2"} && m.Addresses[0] == patchAddress{Street: "Oak", Zip: "3"}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newPatchModel()
+			patch, err := DecodeJSONPatch([]byte(test.body), nestedPatchFields, m)
+			if err != nil {
+				t.Fatalf("Did not expect error and got: %s", err)
+			}
+
+			if !reflect.DeepEqual(patch.Fields(), test.fields) {
+				t.Errorf("Expected fields %v, got %v", test.fields, patch.Fields())
+			}
+
+			if !test.expected(m) {
+				t.Errorf("Unexpected result %+v %s", m, m.Settings.JSON)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name string
+		body string
+	}{
+		{"Unknown op", `[{"op":"swap","path":"/firstName"}]`},
+		{"Missing value", `[{"op":"add","path":"/firstName"}]`},
+		{"Invalid pointer", `[{"op":"add","path":"firstName","value":"a"}]`},
+		{"Missing member", `[{"op":"remove","path":"/settings/missing"}]`},
+		{"Invalid index", `[{"op":"add","path":"/tags/5","value":"a"}]`},
+		{"Move into itself", `[{"op":"move","from":"/settings","path":"/settings/a"}]`},
+		{"Readonly member", `[{"op":"replace","path":"/id","value":"2"}]`},
+		{"Readonly nested member", `[{"op":"replace","path":"/address/street","value":"Elm"}]`},
+		{"Replace a member that only has some settable members", `[{"op":"replace","path":"/address","value":{"zip":"2"}}]`},
+		{"Add an element to an array that only has some settable members", `[{"op":"add","path":"/addresses/-","value":{"zip":"2"}}]`},
+		{"Unknown nested member", `[{"op":"add","path":"/address/city","value":"x"}]`},
+		{"Not an array", `{"op":"add"}`},
+		{"Replace the whole document", `[{"op":"replace","path":"","value":{}}]`},
+	}
+
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeJSONPatch([]byte(test.body), nestedPatchFields, newPatchModel())
+			if err == nil || errors.Is(err, ErrPatchTestFailed) {
+				t.Errorf("Expected an error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Return ErrPatchTestFailed", func(t *testing.T) {
+		m := newPatchModel()
+		_, err := DecodeJSONPatch([]byte(`[{"op":"test","path":"/firstName","value":"Bob"},{"op":"replace","path":"/firstName","value":"Anne"}]`), patchFields, m)
+		if !errors.Is(err, ErrPatchTestFailed) {
+			t.Errorf("Expected ErrPatchTestFailed, got %v", err)
+		}
+
+		if m.FirstName.String.String != "Ann" {
+			t.Errorf("Expected the object to be unchanged, got %s", m.FirstName.String.String)
+		}
+	})
+
+	t.Run("Test the whole document", func(t *testing.T) {
+		doc, _ := json.Marshal(newPatchModel())
+		if _, err := DecodeJSONPatch([]byte(`[{"op":"test","path":"","value":`+string(doc)+`}]`), patchFields, newPatchModel()); err != nil {
+			t.Errorf("Did not expect error and got: %s", err)
+		}
+
+		_, err := DecodeJSONPatch([]byte(`[{"op":"test","path":"","value":{}}]`), patchFields, newPatchModel())
+		if !errors.Is(err, ErrPatchTestFailed) {
+			t.Errorf("Expected ErrPatchTestFailed, got %v", err)
+		}
+	})
+}
+
+func TestJsonEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{"Equal numbers written differently", `[1, {"a": 1.50}]`, `[1.0, {"a": 1.5e0}]`, true},
+		{"Large integers that a float64 rounds to the same value", `9007199254740993`, `9007199254740992`, false},
+		{"Equal large integers", `{"n": 12345678901234567890}`, `{"n": 12345678901234567890}`, true},
+		{"Different members", `{"a": 1}`, `{"a": 1, "b": 2}`, false},
+		{"Different types", `"1"`, `1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b interface{}
+			unmarshalNumber([]byte(tt.a), &a)
+			unmarshalNumber([]byte(tt.b), &b)
+
+			if jsonEqual(a, b) != tt.expected {
+				t.Errorf("Expected %t", tt.expected)
+			}
+		})
+	}
+}
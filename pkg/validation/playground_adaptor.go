@@ -1,12 +1,15 @@
 package validation
 
 import (
+	"context"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 
+	"github.com/go-playground/universal-translator"
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
 	"gopkg.in/go-playground/validator.v9"
 )
@@ -18,16 +21,48 @@ type PlaygroundValidatorAdaptor interface {
 
 type PlaygroundValidator struct {
 	validator PlaygroundValidatorAdaptor
+	// uni has the translators that the messages of the validator are registered on
+	uni *ut.UniversalTranslator
 }
 
 func (pgv *PlaygroundValidator) Var(field interface{}, options interface{}) error {
-	err := pgv.validator.Var(field, options.(string))
+	return pgv.VarContext(context.Background(), field, options)
+}
+
+// VarContext validates the field the same as Var, the database rules query with the context
+func (pgv *PlaygroundValidator) VarContext(ctx context.Context, field interface{}, options interface{}) error {
+	v, ok := pgv.validator.(*validator.Validate)
+	if !ok {
+		return pgv.transformErrors(pgv.validator.Var(field, options.(string)))
+	}
+
+	// the database rules report a failed query through the context, see RegisterDatabaseRules
+	ctx, queryErr := withQueryError(ctx)
+	err := v.VarCtx(ctx, field, options.(string))
+	if *queryErr != nil {
+		return *queryErr
+	}
 
 	return pgv.transformErrors(err)
 }
 
 func (pgv *PlaygroundValidator) Struct(s interface{}) error {
-	err := pgv.validator.Struct(s)
+	return pgv.StructContext(context.Background(), s)
+}
+
+// StructContext validates the struct the same as Struct, the database rules query with the context
+func (pgv *PlaygroundValidator) StructContext(ctx context.Context, s interface{}) error {
+	v, ok := pgv.validator.(*validator.Validate)
+	if !ok {
+		return pgv.transformErrors(pgv.validator.Struct(s))
+	}
+
+	// the database rules report a failed query through the context, see RegisterDatabaseRules
+	ctx, queryErr := withQueryError(ctx)
+	err := v.StructCtx(ctx, s)
+	if *queryErr != nil {
+		return *queryErr
+	}
 
 	return pgv.transformErrors(err)
 }
@@ -50,7 +85,9 @@ func (pgv PlaygroundValidator) transformErrors(err error) error {
 
 	errs := make([]Error, 0)
 	for _, e := range validationErrs {
-		errs = append(errs, newFieldError(e.Field(), e.ActualTag(), e.Value()))
+		fe := newFieldError(e.Field(), e.ActualTag(), e.Value())
+		fe.fieldError = e
+		errs = append(errs, fe)
 	}
 
 	if len(errs) == 0 {
@@ -60,17 +97,89 @@ func (pgv PlaygroundValidator) transformErrors(err error) error {
 	return consolidateValidationErrors(errs)
 }
 
+func (pgv *PlaygroundValidator) RegisterRule(name string, fn RuleFunc) error {
+	v, err := pgv.validate()
+	if err != nil {
+		return err
+	}
+
+	return v.RegisterValidation(name, func(fl validator.FieldLevel) bool {
+		return fn(fl.Field(), fl.Param())
+	})
+}
+
+func (pgv *PlaygroundValidator) RegisterStructRule(fn StructRuleFunc, models ...interface{}) error {
+	v, err := pgv.validate()
+	if err != nil {
+		return err
+	}
+
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		current := sl.Current()
+		fn(current.Interface(), func(field string, rule string) {
+			var value interface{}
+			name := field
+			if f, ok := getStructFieldByJsonTag(current, field); ok {
+				value = current.FieldByIndex(f.Index).Interface()
+				name = f.Name
+			}
+
+			sl.ReportError(value, field, name, rule, "")
+		})
+	}, models...)
+
+	return nil
+}
+
+func (pgv *PlaygroundValidator) RegisterType(fn TypeFunc, values ...interface{}) error {
+	v, err := pgv.validate()
+	if err != nil {
+		return err
+	}
+
+	v.RegisterCustomTypeFunc(validator.CustomTypeFunc(fn), values...)
+
+	return nil
+}
+
+// validate returns the playground instance that rules are registered on
+func (pgv *PlaygroundValidator) validate() (*validator.Validate, error) {
+	v, ok := pgv.validator.(*validator.Validate)
+	if !ok {
+		return nil, errors.New("rules can only be registered on a go-playground validator")
+	}
+
+	return v, nil
+}
+
+// getStructFieldByJsonTag returns the struct member that JSONTagNameFunc names field
+func getStructFieldByJsonTag(v reflect.Value, field string) (reflect.StructField, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if JSONTagNameFunc(t.Field(i)) == field {
+			return t.Field(i), true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
 // NewPlaygroundValidator instantiates a new instance of the PlaygroundValidator.  It is responsible for adding
 // any additional functions and tags to the base library
 func NewPlaygroundValidator() Validator {
 	v := validator.New()
 	v.RegisterValidation("notblank", playgroundStringNotBlank)
 	v.RegisterValidation("uuidnotblank", playgroundUuidNotBlank)
-	v.RegisterCustomTypeFunc(ValidateValuer, types.NullString{})
+	v.RegisterCustomTypeFunc(ValidateValuer, types.NullString{}, types.NullInt{}, types.NullFloat{}, types.NullBool{}, types.NullUUID{}, types.NullJSON{})
+	v.RegisterCustomTypeFunc(ValidateTime, types.Datetime{}, types.Date{}, types.NullDatetime{}, types.NullDate{})
 	v.RegisterTagNameFunc(JSONTagNameFunc)
+	registerDateRules(v)
+	uni := newUniversalTranslator()
+	registerTranslations(v, uni)
 
 	return &PlaygroundValidator{
 		validator: v,
+		uni:       uni,
 	}
 }
 
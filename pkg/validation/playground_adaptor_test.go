@@ -3,6 +3,7 @@ package validation
 import (
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
@@ -158,6 +159,54 @@ func TestValidateValuer(t *testing.T) {
 	})
 }
 
+func TestNewPlaygroundValidator_NullTypes(t *testing.T) {
+	type model struct {
+		Count    types.NullInt   `json:"count" validate:"required,min=2"`
+		Ratio    types.NullFloat `json:"ratio" validate:"omitempty,lte=1"`
+		Active   types.NullBool  `json:"active" validate:"required"`
+		SchoolId types.NullUUID  `json:"schoolId" validate:"uuidnotblank"`
+		Settings types.NullJSON  `json:"settings" validate:"required"`
+	}
+
+	pgv := NewPlaygroundValidator()
+	valid := model{
+		Count:    types.NewNullInt(2, true),
+		Ratio:    types.NewNullFloat(0.5, true),
+		Active:   types.NewNullBool(true, true),
+		SchoolId: types.NewNullUUID("f47ac10b-58cc-4372-a567-0e02b2c3d479", true),
+		Settings: types.NewNullJSON([]byte(`{}`), true),
+	}
+
+	if err := pgv.Struct(valid); err != nil {
+		t.Errorf("Did not expect error and got: %s", err)
+	}
+
+	invalid := model{
+		Count:    types.NewNullInt(1, true),
+		Ratio:    types.NewNullFloat(1.5, true),
+		Active:   types.NewNullBool(false, false),
+		SchoolId: types.NewNullUUID("", false),
+		Settings: types.NewNullJSON(nil, false),
+	}
+
+	err := pgv.Struct(invalid)
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+
+	expected := map[string]string{"count": "min", "ratio": "lte", "active": "required", "settings": "required"}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+
+	for _, e := range errs {
+		if expected[e.Field()] != e.Rule() {
+			t.Errorf("Did not expect %s to fail %s", e.Field(), e.Rule())
+		}
+	}
+}
+
 func TestJSONTagNameFunc(t *testing.T) {
 
 	type NoJSONTag struct {
@@ -196,3 +245,100 @@ func TestJSONTagNameFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestPlaygroundValidator_RegisterRule(t *testing.T) {
+	if _, ok := NewPlaygroundValidator().(RuleRegistrar); !ok {
+		t.Fatal("Expected the validator to be a RuleRegistrar")
+	}
+
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+
+	err := pgv.RegisterRule("statecode", func(field reflect.Value, param string) bool {
+		return len(field.String()) == 2 && strings.ToUpper(field.String()) == field.String()
+	})
+	if err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	type model struct {
+		State string `json:"state" validate:"statecode"`
+	}
+
+	if err := pgv.Struct(model{State: "CA"}); err != nil {
+		t.Errorf("Did not expect error and got: %s", err)
+	}
+
+	var errs Errors
+	if err := pgv.Struct(model{State: "Cal"}); !errors.As(err, &errs) || errs[0].Field() != "state" || errs[0].Rule() != "statecode" {
+		t.Errorf("Expected the statecode rule to fail for state, got %v", err)
+	}
+
+	t.Run("Return an error for an invalid name", func(t *testing.T) {
+		if err := pgv.RegisterRule("", func(reflect.Value, string) bool { return true }); err == nil {
+			t.Error("Expected error and got none")
+		}
+	})
+
+	t.Run("Return an error when the adaptor isn't the playground library", func(t *testing.T) {
+		pv := PlaygroundValidator{validator: MockPlaygroundValidatorAdaptor{}}
+		if err := pv.RegisterRule("statecode", func(reflect.Value, string) bool { return true }); err == nil {
+			t.Error("Expected error and got none")
+		}
+	})
+}
+
+func TestPlaygroundValidator_RegisterStructRule(t *testing.T) {
+	type model struct {
+		MinGrade int `json:"minGrade"`
+		MaxGrade int `json:"maxGrade"`
+	}
+
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+	err := pgv.RegisterStructRule(func(s interface{}, report func(field string, rule string)) {
+		if m := s.(model); m.MaxGrade < m.MinGrade {
+			report("maxGrade", "gtefield")
+		}
+	}, model{})
+	if err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	if err := pgv.Struct(model{MinGrade: 1, MaxGrade: 5}); err != nil {
+		t.Errorf("Did not expect error and got: %s", err)
+	}
+
+	var errs Errors
+	if err := pgv.Struct(model{MinGrade: 5, MaxGrade: 1}); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Expected 1 field error, got %v", err)
+	}
+
+	if errs[0].Field() != "maxGrade" || errs[0].Rule() != "gtefield" || errs[0].Value() != 1 {
+		t.Errorf("Expected gtefield to fail for maxGrade with 1, got %s %s %v", errs[0].Field(), errs[0].Rule(), errs[0].Value())
+	}
+}
+
+func TestPlaygroundValidator_RegisterType(t *testing.T) {
+	type grade struct {
+		level int
+	}
+
+	type model struct {
+		Grade grade `json:"grade" validate:"max=12"`
+	}
+
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+	err := pgv.RegisterType(func(field reflect.Value) interface{} {
+		return field.Interface().(grade).level
+	}, grade{})
+	if err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	if err := pgv.Struct(model{Grade: grade{level: 10}}); err != nil {
+		t.Errorf("Did not expect error and got: %s", err)
+	}
+
+	if err := pgv.Struct(model{Grade: grade{level: 13}}); err == nil {
+		t.Error("Expected error and got none")
+	}
+}
@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
+)
+
+// ErrBodyTooLarge is returned by a LimitReader once more than the limit has been read
+var ErrBodyTooLarge = errors.New("request body is too large")
+
+// limitedReader is io.LimitedReader except it returns ErrBodyTooLarge instead of io.EOF when the limit is exceeded
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// LimitReader returns a reader that fails with ErrBodyTooLarge when r has more than limit bytes
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	return &limitedReader{r: r, remaining: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// one byte more than the limit is read to find out whether the body is too large
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+// DecodeReader behaves the same as DecodePatch but decodes the body from a reader, e.g. http.Request.Body wrapped by
+// LimitReader.  The body must be a single JSON value, anything after it is an error, see ReadJSON.
+func DecodeReader(r io.Reader, validFields []string, objPtr interface{}) (types.Patch, error) {
+	body, err := ReadJSON(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodePatch(body, validFields, objPtr)
+}
+
+// ReadJSON reads a single JSON value from the reader, anything after it is an error.  The value is kept in memory
+// because the repeated properties are checked before it is decoded into a struct, wrap the reader with LimitReader to
+// bound its size.
+func ReadJSON(r io.Reader) (json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+
+	var body json.RawMessage
+	if err := dec.Decode(&body); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return nil, err
+		}
+
+		return nil, errors.New("request body must only contain a single JSON value")
+	}
+
+	return body, nil
+}
+
+// checkDuplicateKeys returns a field error for every property that is repeated within an object, which encoding/json
+// would otherwise silently overwrite.  Nested properties are reported with a dotted path.
+func checkDuplicateKeys(data []byte) error {
+	errs := make(Errors, 0)
+	if err := walkDuplicateKeys(json.NewDecoder(bytes.NewReader(data)), "", &errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field() < errs[j].Field() })
+		return errs
+	}
+
+	return nil
+}
+
+func walkDuplicateKeys(dec *json.Decoder, path string, errs *Errors) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key := t.(string)
+			if seen[key] {
+				*errs = append(*errs, FieldError{field: joinPath(path, key), rule: RULE_DUPLICATE, message: "This property is duplicated."})
+			}
+			seen[key] = true
+
+			if err := walkDuplicateKeys(dec, joinPath(path, key), errs); err != nil {
+				return err
+			}
+		}
+
+		_, err = dec.Token()
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := walkDuplicateKeys(dec, joinPath(path, strconv.Itoa(i)), errs); err != nil {
+				return err
+			}
+		}
+
+		_, err = dec.Token()
+	}
+
+	return err
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestLimitReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		limit int64
+		err   error
+	}{
+		{"Read a body smaller than the limit", "123", 4, nil},
+		{"Read a body equal to the limit", "1234", 4, nil},
+		{"Fail for a body larger than the limit", "12345", 4, ErrBodyTooLarge},
+		{"Fail for any body with a limit of zero", "1", 0, ErrBodyTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := ioutil.ReadAll(LimitReader(strings.NewReader(test.input), test.limit))
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected error %v, got %v", test.err, err)
+			}
+
+			if int64(len(body)) > test.limit {
+				t.Errorf("Expected at most %d bytes, got %d", test.limit, len(body))
+			}
+		})
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	type TestStruct struct {
+		Name    string `json:"name"`
+		Address struct {
+			Zip string `json:"zip"`
+		} `json:"address"`
+	}
+
+	t.Run("Decode the body", func(t *testing.T) {
+		testStruct := TestStruct{}
+		patch, err := DecodeReader(strings.NewReader(`{"name":"value"}  `), []string{}, &testStruct)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if testStruct.Name != "value" || !patch.Get("Name").IsSet() {
+			t.Errorf("Expected the name to be decoded, got %+v", testStruct)
+		}
+	})
+
+	t.Run("Reject anything after the JSON value", func(t *testing.T) {
+		for _, body := range []string{`{"name":"value"} {"name":"other"}`, `{"name":"value"}garbage`} {
+			if _, err := DecodeReader(strings.NewReader(body), []string{}, &TestStruct{}); err == nil {
+				t.Errorf("Expected error for %s and got none", body)
+			}
+		}
+	})
+
+	t.Run("Reject duplicate properties", func(t *testing.T) {
+		_, err := DecodeReader(strings.NewReader(`{"name":"a","address":{"zip":"1","zip":"2"},"name":"b"}`), []string{}, &TestStruct{})
+
+		var errs Errors
+		if !errors.As(err, &errs) || errs.Error() != "address.zip: This property is duplicated. || name: This property is duplicated." {
+			t.Errorf("Expected duplicate errors, got %v", err)
+		}
+
+		if errs[0].Rule() != RULE_DUPLICATE {
+			t.Errorf("Expected the duplicate rule, got %s", errs[0].Rule())
+		}
+	})
+
+	t.Run("Return ErrBodyTooLarge", func(t *testing.T) {
+		_, err := DecodeReader(LimitReader(strings.NewReader(`{"name":"value"}`), 5), []string{}, &TestStruct{})
+		if !errors.Is(err, ErrBodyTooLarge) {
+			t.Errorf("Expected ErrBodyTooLarge, got %v", err)
+		}
+	})
+}
@@ -1,17 +1,63 @@
 package validation
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
 )
 
 // DecodeRequest will take a json request body and validate that all the top-level fields are in members of the struct
 // and that each key is within the validaFields array.  It will match the request parameter by its json tag name if it
 // exists and it will match the valid fields By the name of the struct member.
 func DecodeRequest(body []byte, validFields []string, objPtr interface{}) error {
+	_, err := DecodeRequestFields(body, validFields, objPtr)
+
+	return err
+}
+
+// DecodeRequestFields behaves the same as DecodeRequest and also returns the names of the struct members that were
+// present in the request body, sorted by name.  They can be passed to db.FieldsUpdater.UpdateFields to only save what
+// changed.
+func DecodeRequestFields(body []byte, validFields []string, objPtr interface{}) ([]string, error) {
+	patch, err := DecodePatch(body, validFields, objPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	return patch.Fields(), nil
+}
+
+// DecodePatch behaves the same as DecodeRequest and also returns the members of the request body by struct member name
+// so a handler can tell apart the members that were set, cleared with null or left out.
+func DecodePatch(body []byte, validFields []string, objPtr interface{}) (types.Patch, error) {
+	rv := checkValidFields(validFields, objPtr)
+
+	requestValues := make(map[string]json.RawMessage)
+	err := json.Unmarshal(body, &requestValues)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDuplicateKeys(body); err != nil {
+		return nil, err
+	}
+
+	if errs := checkProperties("", requestValues, rv.Elem().Type(), rv.Elem(), validFields); len(errs) > 0 {
+		return nil, sortErrors(errs)
+	}
+
+	return decodeMembers(requestValues, validFields, rv.Elem())
+}
+
+// checkValidFields panics when the object isn't a pointer or one of the valid fields isn't a member of its struct
+func checkValidFields(validFields []string, objPtr interface{}) reflect.Value {
 	rv := reflect.ValueOf(objPtr)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		panic(errors.New("obj should be a pointer to a struct"))
@@ -19,86 +65,299 @@ func DecodeRequest(body []byte, validFields []string, objPtr interface{}) error
 
 	objType := rv.Elem().Type()
 	for _, fieldName := range validFields {
-		_, ok := objType.FieldByName(fieldName)
-		if !ok {
+		if !hasMemberPath(objType, fieldName) {
 			panic(fmt.Sprintf("%s is not a part of %s", fieldName, objType.String()))
 		}
 	}
 
-	requestValues := make(map[string]json.RawMessage)
-	err := json.Unmarshal(body, &requestValues)
-	if err != nil {
-		return err
+	return rv
+}
+
+// decodeMembers decodes each top level member of the request individually into the struct.  The properties have to be
+// checked beforehand, validFields is only used to tell which members can't be replaced as a whole.
+func decodeMembers(requestValues map[string]json.RawMessage, validFields []string, v reflect.Value) (types.Patch, error) {
+	members := getJsonMembers(v.Type())
+	patch := make(types.Patch, len(requestValues))
+	errs := make(Errors, 0)
+	for jsonKey, rawJson := range requestValues {
+		m := members[jsonKey]
+		patch[m.name] = types.Optional{Raw: rawJson, Present: true}
+		field := fieldByIndex(v, m.index)
+		fieldValuePtr := reflect.New(field.Type())
+
+		if decodesObject(field.Type()) && !isNull(rawJson) {
+			// nested objects are decoded into a copy of the current value so the members that aren't in the request are kept
+			fieldValuePtr.Elem().Set(copyStruct(field))
+		} else if field.Kind() == reflect.Slice && isRestricted(validFields, m.name) {
+			// only members of the elements can be set so they are merged into a copy of the current elements
+			fieldValuePtr.Elem().Set(reflect.AppendSlice(reflect.MakeSlice(field.Type(), 0, field.Len()), field))
+		}
+
+		err := json.Unmarshal(rawJson, fieldValuePtr.Interface())
+		if err != nil {
+			errs = append(errs, FieldError{field: jsonKey, rule: RULE_TYPE, value: rawJson, message: err.Error(), unprefixed: true})
+		} else {
+			field.Set(fieldValuePtr.Elem())
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, sortErrors(errs)
 	}
 
-	fieldMap := getFieldsMappedToJsonTag(objType)
+	return patch, nil
+}
+
+// checkProperties returns the field errors of the properties that aren't members of the type or that can't be set.
+// Nested objects and arrays of objects are checked as well and their properties are reported with a dotted path, e.g.
+// address.zip or addresses.0.zip.  The current value, which is invalid when it doesn't exist, is needed to tell if the
+// elements of an array are only changed.
+func checkProperties(prefix string, values map[string]json.RawMessage, t reflect.Type, v reflect.Value, validFields []string) Errors {
+	members := getJsonMembers(t)
 
-	errs := make([]string, 0)
-	for jsonKey := range requestValues {
-		if _, ok := fieldMap[jsonKey]; !ok {
-			errs = append(errs, fmt.Sprintf("%s: This property does not exist.", jsonKey))
+	errs := make(Errors, 0)
+	for jsonKey, rawJson := range values {
+		path := prefix + jsonKey
+		m, ok := members[jsonKey]
+		if !ok {
+			errs = append(errs, FieldError{field: path, rule: RULE_UNKNOWN, value: rawJson, message: "This property does not exist."})
 			continue
 		}
 
 		// don't add an additional validation checks if the array is empty
-		if len(validFields) == 0 {
-			continue
+		nestedFields := validFields
+		if len(validFields) > 0 {
+			if nestedFields, ok = getNestedFields(validFields, m.name); !ok {
+				errs = append(errs, newReadonlyError(path, rawJson))
+				continue
+			}
 		}
 
-		field := fieldMap[jsonKey]
-		canBeSet := false
-		for _, f := range validFields {
-			if f == field {
-				canBeSet = true
-				break
+		errs = append(errs, checkNestedProperties(path, rawJson, m.typ, memberValue(v, m.index), nestedFields)...)
+	}
+
+	return errs
+}
+
+// checkNestedProperties checks the properties of a value that is decoded into a struct or a slice of structs.  Values
+// of the wrong type are skipped, they are reported when they are decoded.  When only some nested members can be set the
+// value can't be cleared and the elements of an array can't be added or removed, only their members can be set.
+func checkNestedProperties(path string, rawJson json.RawMessage, t reflect.Type, v reflect.Value, validFields []string) Errors {
+	restricted := len(validFields) > 0
+	if restricted && isNull(rawJson) {
+		return Errors{newReadonlyError(path, rawJson)}
+	}
+
+	t = indirectType(t)
+	v = reflect.Indirect(v)
+
+	if decodesObject(t) {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(rawJson, &values); err != nil || values == nil {
+			return nil
+		}
+
+		return checkProperties(path+".", values, t, v, validFields)
+	}
+
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		var items []json.RawMessage
+		if !decodesObject(t.Elem()) || json.Unmarshal(rawJson, &items) != nil {
+			return nil
+		}
+
+		if restricted && (!v.IsValid() || len(items) != v.Len()) {
+			return Errors{newReadonlyError(path, rawJson)}
+		}
+
+		errs := make(Errors, 0)
+		for i, item := range items {
+			var elem reflect.Value
+			if v.IsValid() && i < v.Len() {
+				elem = v.Index(i)
 			}
+
+			errs = append(errs, checkNestedProperties(path+"."+strconv.Itoa(i), item, t.Elem(), elem, validFields)...)
+		}
+
+		return errs
+	}
+
+	return nil
+}
+
+// isRestricted returns true when only some of the nested members of the member can be set
+func isRestricted(validFields []string, name string) bool {
+	if len(validFields) == 0 {
+		return false
+	}
+
+	nested, _ := getNestedFields(validFields, name)
+
+	return len(nested) > 0
+}
+
+func newReadonlyError(path string, rawJson json.RawMessage) FieldError {
+	return FieldError{field: path, rule: RULE_READONLY, value: rawJson, message: "This property is not allowed to be set."}
+}
+
+func sortErrors(errs Errors) Errors {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field() < errs[j].Field() })
+
+	return errs
+}
+
+func isNull(rawJson json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(rawJson), []byte("null"))
+}
+
+// getNestedFields returns the valid fields of a member.  An empty list is returned when the whole member can be set,
+// e.g. for Address, and the nested paths when only some of its members can, e.g. Zip for Address.Zip.  False is
+// returned when the member can't be set at all.
+func getNestedFields(validFields []string, name string) ([]string, bool) {
+	nested := make([]string, 0)
+	for _, f := range validFields {
+		if f == name {
+			return []string{}, true
 		}
 
-		if !canBeSet {
-			errs = append(errs, fmt.Sprintf("%s: This property is not allowed to be set.", jsonKey))
+		if strings.HasPrefix(f, name+".") {
+			nested = append(nested, strings.TrimPrefix(f, name+"."))
 		}
 	}
 
-	if len(errs) > 0 {
-		return errors.New(strings.Join(errs, ERROR_DELIMITER))
+	return nested, len(nested) > 0
+}
+
+// hasMemberPath returns true if the dotted path of struct member names exists, e.g. Address.Zip.  Slices are
+// traversed so Addresses.Zip refers to the Zip of every address.
+func hasMemberPath(t reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		t = indirectType(t)
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = indirectType(t.Elem())
+		}
+
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return false
+		}
+
+		t = f.Type
 	}
 
-	// decode each key in the request individually
-	for jsonKey, rawJson := range requestValues {
-		field := rv.Elem().FieldByName(fieldMap[jsonKey])
-		fieldValuePtr := reflect.New(field.Type()).Interface()
+	return true
+}
 
-		err := json.Unmarshal(rawJson, fieldValuePtr)
-		if err != nil {
-			errs = append(errs, err.Error())
-		} else {
-			field.Set(reflect.ValueOf(fieldValuePtr).Elem())
+// jsonMember is a struct member that a json property is decoded into
+type jsonMember struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+// getJsonMembers returns the members of a struct by json property the same way encoding/json matches them.  The
+// members of embedded structs without a json tag are promoted unless the struct has a member with the same property.
+func getJsonMembers(t reflect.Type) map[string]jsonMember {
+	members := make(map[string]jsonMember, t.NumField())
+	embedded := make([]reflect.StructField, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("json"), ",")[0]
+		if key == "-" {
+			continue
 		}
+
+		if f.Anonymous && key == "" && indirectType(f.Type).Kind() == reflect.Struct {
+			embedded = append(embedded, f)
+			continue
+		}
+
+		// unexported members are ignored by encoding/json
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if key == "" {
+			key = f.Name
+		}
+
+		members[key] = jsonMember{name: f.Name, index: f.Index, typ: f.Type}
 	}
 
-	if len(errs) > 0 {
-		return errors.New(strings.Join(errs, ERROR_DELIMITER))
+	for _, f := range embedded {
+		for key, m := range getJsonMembers(indirectType(f.Type)) {
+			if _, ok := members[key]; !ok {
+				m.index = append([]int{f.Index[0]}, m.index...)
+				members[key] = m
+			}
+		}
 	}
 
-	return nil
+	return members
 }
 
-// getFieldsMappedToJsonTag returns a map of fields where the key is the json tag or the field name if a json
-// field name isn't set and the value is the real field name
-func getFieldsMappedToJsonTag(structType reflect.Type) map[string]string {
-	fields := make(map[string]string, structType.NumField())
+// memberValue returns the nested field without allocating nil embedded pointers, the value is invalid when one is nil
+func memberValue(v reflect.Value, index []int) reflect.Value {
+	for _, x := range index {
+		v = reflect.Indirect(v)
+		if !v.IsValid() {
+			return v
+		}
 
-	for i := 0; i < structType.NumField(); i++ {
-		f := structType.Field(i)
-		key := f.Name
+		v = v.Field(x)
+	}
 
-		jsonTag, ok := f.Tag.Lookup("json")
-		if ok {
-			key = strings.Split(jsonTag, ",")[0]
+	return v
+}
+
+// fieldByIndex returns the nested field, nil embedded pointers are allocated so the field can be set
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
 		}
 
-		fields[key] = f.Name
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decodesObject returns true if encoding/json decodes a JSON object into the members of the type, which is a struct
+// that doesn't implement json.Unmarshaler such as types.NullString.
+func decodesObject(t reflect.Type) bool {
+	t = indirectType(t)
+
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+// copyStruct returns a copy of the value where a pointer points to a copy of its struct
+func copyStruct(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return v
+	}
+
+	c := reflect.New(v.Type().Elem())
+	c.Elem().Set(v.Elem())
+
+	return c
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
 	}
 
-	return fields
+	return t
 }
@@ -2,7 +2,11 @@ package validation
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
+
+	"github.com/illuminateeducation/rest-service-lib-go/pkg/types"
 )
 
 func TestDecodeRequest(t *testing.T) {
@@ -108,3 +112,234 @@ func TestDecodeRequest(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodeRequestFields(t *testing.T) {
+	type TestStruct struct {
+		Name   string `json:"name"`
+		Age    int    `json:"age"`
+		Active bool
+	}
+
+	t.Run("Return the struct members that were in the request", func(t *testing.T) {
+		testStruct := TestStruct{Name: "old", Age: 30}
+		fields, err := DecodeRequestFields([]byte(`{"name":"new","Active":true}`), []string{}, &testStruct)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if !reflect.DeepEqual(fields, []string{"Active", "Name"}) {
+			t.Errorf("Expected [Active Name], got %v", fields)
+		}
+
+		if testStruct.Name != "new" || testStruct.Age != 30 || !testStruct.Active {
+			t.Errorf("Expected only the fields in the request to change, got %+v", testStruct)
+		}
+	})
+
+	t.Run("Return the field errors of properties that can't be set", func(t *testing.T) {
+		_, err := DecodeRequestFields([]byte(`{"invalid":"value","age":1}`), []string{"Name"}, &TestStruct{})
+
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Fatalf("Expected 2 field errors, got %v", err)
+		}
+
+		if errs[0].Field() != "age" || errs[0].Rule() != RULE_READONLY {
+			t.Errorf("Expected a readonly rule for age, got %s %s", errs[0].Field(), errs[0].Rule())
+		}
+
+		if errs[1].Field() != "invalid" || errs[1].Rule() != RULE_UNKNOWN {
+			t.Errorf("Expected an unknown rule for invalid, got %s %s", errs[1].Field(), errs[1].Rule())
+		}
+	})
+
+	t.Run("Return the field errors of values that can't be decoded", func(t *testing.T) {
+		_, err := DecodeRequestFields([]byte(`{"name":1}`), []string{}, &TestStruct{})
+
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field() != "name" || errs[0].Rule() != RULE_TYPE {
+			t.Errorf("Expected a type rule for name, got %v", err)
+		}
+	})
+
+	t.Run("Return no fields for an invalid request", func(t *testing.T) {
+		fields, err := DecodeRequestFields([]byte(`{"invalid":"value"}`), []string{}, &TestStruct{})
+		if err == nil || fields != nil {
+			t.Errorf("Expected an error and no fields, got %v and %v", err, fields)
+		}
+	})
+}
+
+func TestDecodePatch(t *testing.T) {
+	type TestStruct struct {
+		FirstName  types.NullString `json:"firstName"`
+		MiddleName types.NullString `json:"middleName"`
+		LastName   types.NullString `json:"lastName"`
+		Nickname   types.Optional   `json:"nickname"`
+	}
+
+	testStruct := TestStruct{
+		FirstName:  types.NewNullString("Ann", true),
+		MiddleName: types.NewNullString("Marie", true),
+		LastName:   types.NewNullString("Smith", true),
+	}
+
+	patch, err := DecodePatch([]byte(`{"firstName":"Anne","middleName":null,"nickname":"Annie"}`), []string{}, &testStruct)
+	if err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	states := map[string]int{
+		"FirstName":  types.OPTIONAL_SET,
+		"MiddleName": types.OPTIONAL_NULL,
+		"LastName":   types.OPTIONAL_ABSENT,
+		"Nickname":   types.OPTIONAL_SET,
+	}
+
+	for field, state := range states {
+		if actual := patch.Get(field).State(); actual != state {
+			t.Errorf("Expected %s to have state %d, got %d", field, state, actual)
+		}
+	}
+
+	if !reflect.DeepEqual(patch.Fields(), []string{"FirstName", "MiddleName", "Nickname"}) {
+		t.Errorf("Expected the fields that were set or cleared, got %v", patch.Fields())
+	}
+
+	if testStruct.MiddleName.Valid || testStruct.LastName.String.String != "Smith" || !testStruct.Nickname.IsSet() {
+		t.Errorf("Expected the request to be applied to the struct, got %+v", testStruct)
+	}
+}
+
+func TestDecodeRequest_Nested(t *testing.T) {
+	type Address struct {
+		Street string           `json:"street"`
+		Zip    types.NullString `json:"zip"`
+	}
+
+	type Audit struct {
+		CreatedBy string `json:"createdBy"`
+		Internal  string `json:"-"`
+	}
+
+	type Person struct {
+		Audit
+		Name      string    `json:"name"`
+		Address   Address   `json:"address"`
+		Previous  *Address  `json:"previous"`
+		Addresses []Address `json:"addresses"`
+		secret    string
+	}
+
+	t.Run("Report unknown properties of nested objects and arrays with their path", func(t *testing.T) {
+		err := DecodeRequest([]byte(`{"address":{"zip":"1","zipCode":"2"},"previous":{"city":"x"},"addresses":[{},{"unit":1}],"secret":"x"}`), []string{}, &Person{})
+
+		var errs Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("Expected field errors, got %v", err)
+		}
+
+		expected := "address.zipCode: This property does not exist. || addresses.1.unit: This property does not exist. || previous.city: This property does not exist. || secret: This property does not exist."
+		if errs.Error() != expected {
+			t.Errorf("Expected %s, got %s", expected, errs.Error())
+		}
+	})
+
+	t.Run("Allow nested paths in the valid fields", func(t *testing.T) {
+		person := Person{Address: Address{Street: "Main", Zip: types.NewNullString("1", true)}}
+		fields, err := DecodeRequestFields([]byte(`{"address":{"zip":"2"}}`), []string{"Address.Zip"}, &person)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if !reflect.DeepEqual(fields, []string{"Address"}) {
+			t.Errorf("Expected [Address], got %v", fields)
+		}
+
+		if person.Address.Street != "Main" || person.Address.Zip.String.String != "2" {
+			t.Errorf("Expected only the zip to change, got %+v", person.Address)
+		}
+
+		var errs Errors
+		err = DecodeRequest([]byte(`{"address":{"street":"Elm"},"name":"x"}`), []string{"Address.Zip"}, &person)
+		if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Field() != "address.street" || errs[0].Rule() != RULE_READONLY || errs[1].Field() != "name" {
+			t.Errorf("Expected address.street and name to be readonly, got %v", err)
+		}
+	})
+
+	t.Run("Only change the allowed nested members", func(t *testing.T) {
+		newPerson := func() Person {
+			return Person{
+				Address:   Address{Street: "Main", Zip: types.NewNullString("1", true)},
+				Previous:  &Address{Street: "Elm"},
+				Addresses: []Address{{Street: "Oak"}, {Street: "Pine"}},
+			}
+		}
+
+		validFields := []string{"Address.Zip", "Previous.Zip", "Addresses.Zip"}
+		person := newPerson()
+		err := DecodeRequest([]byte(`{"addresses":[{"zip":"8"},{"zip":"9"}]}`), validFields, &person)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if person.Addresses[0].Street != "Oak" || person.Addresses[1].Street != "Pine" || person.Addresses[1].Zip.String.String != "9" {
+			t.Errorf("Expected only the zips of the addresses to change, got %+v", person.Addresses)
+		}
+
+		person = newPerson()
+		var errs Errors
+		err = DecodeRequest([]byte(`{"address":null,"previous":null,"addresses":[{"zip":"9"}]}`), validFields, &person)
+		if !errors.As(err, &errs) || len(errs) != 3 || errs[0].Field() != "address" || errs[1].Field() != "addresses" || errs[2].Field() != "previous" {
+			t.Fatalf("Expected address, addresses and previous to be readonly, got %v", err)
+		}
+
+		if !reflect.DeepEqual(person, newPerson()) {
+			t.Errorf("Expected the person to be unchanged, got %+v", person)
+		}
+	})
+
+	t.Run("Merge a nested pointer without changing the original", func(t *testing.T) {
+		previous := &Address{Street: "Main"}
+		person := Person{Previous: previous}
+		if err := DecodeRequest([]byte(`{"previous":{"zip":"2"}}`), []string{"Previous"}, &person); err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if person.Previous.Street != "Main" || person.Previous.Zip.String.String != "2" || previous.Zip.Valid {
+			t.Errorf("Expected a merged copy, got %+v and %+v", person.Previous, previous)
+		}
+
+		if err := DecodeRequest([]byte(`{"previous":null}`), []string{"Previous"}, &person); err != nil || person.Previous != nil {
+			t.Errorf("Expected null to clear the pointer, got %v, %v", person.Previous, err)
+		}
+	})
+
+	t.Run("Decode the members of embedded structs", func(t *testing.T) {
+		person := Person{}
+		fields, err := DecodeRequestFields([]byte(`{"createdBy":"me"}`), []string{"CreatedBy"}, &person)
+		if err != nil {
+			t.Fatalf("Did not expect error and got: %s", err)
+		}
+
+		if person.CreatedBy != "me" || !reflect.DeepEqual(fields, []string{"CreatedBy"}) {
+			t.Errorf("Expected createdBy to be decoded, got %+v %v", person, fields)
+		}
+
+		var errs Errors
+		err = DecodeRequest([]byte(`{"Audit":{},"Internal":"x"}`), []string{}, &person)
+		if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Rule() != RULE_UNKNOWN || errs[1].Rule() != RULE_UNKNOWN {
+			t.Errorf("Expected the embedded struct and ignored members to be unknown, got %v", err)
+		}
+	})
+
+	t.Run("The nested valid fields must be part of the struct", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected a panic for an invalid path")
+			}
+		}()
+
+		DecodeRequest([]byte(`{}`), []string{"Address.City"}, &Person{})
+	})
+}
@@ -0,0 +1,349 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+	en_translations "gopkg.in/go-playground/validator.v9/translations/en"
+)
+
+// DEFAULT_LOCALE is used when none of the languages of a request are supported
+const DEFAULT_LOCALE = "en"
+
+// TranslatorProvider is implemented by validators that translate the messages of their errors, see Translate
+type TranslatorProvider interface {
+	// GetTranslator returns the translator of the first supported language of an Accept-Language header
+	GetTranslator(acceptLanguage string) ut.Translator
+}
+
+// newUniversalTranslator returns a translator for every supported locale.  Every validator has its own so the messages
+// that one of them registers don't replace the messages of another.
+func newUniversalTranslator() *ut.UniversalTranslator {
+	return ut.New(en.New(), en.New(), es.New())
+}
+
+// defaultTranslator doesn't have any messages, it is used for validators that don't translate theirs
+var defaultTranslator, _ = newUniversalTranslator().GetTranslator(DEFAULT_LOCALE)
+
+// templates are the messages of the rules that don't come with the validator library.  {0} is replaced by the field
+// and {1} by the parameter of the rule.
+var templates = map[string]map[string]string{
+	"en": {
+		"notblank":      "{0} cannot be blank",
+		"uuidnotblank":  "{0} must be a valid UUID",
+		RULE_UNKNOWN:    "{0} does not exist",
+		RULE_READONLY:   "{0} is not allowed to be set",
+		RULE_TYPE:       "{0} has a value of the wrong type",
+		RULE_DUPLICATE:  "{0} is duplicated",
+		RULE_UNIQUE_IN:  "{0} is already in use",
+		RULE_EXISTS:     "{0} does not reference an existing record",
+		RULE_BEFORE:     "{0} must be before {1}",
+		RULE_AFTER:      "{0} must be after {1}",
+		RULE_PAST:       "{0} must be in the past",
+		RULE_FUTURE:     "{0} must be in the future",
+		RULE_DATE_RANGE: "{0} must be within {1}",
+	},
+	"es": {
+		"notblank":      "{0} no puede estar vacío",
+		"uuidnotblank":  "{0} debe ser un UUID válido",
+		RULE_UNKNOWN:    "{0} no existe",
+		RULE_READONLY:   "{0} no se puede modificar",
+		RULE_TYPE:       "{0} tiene un valor de tipo incorrecto",
+		RULE_DUPLICATE:  "{0} está duplicado",
+		RULE_UNIQUE_IN:  "{0} ya está en uso",
+		RULE_EXISTS:     "{0} no hace referencia a un registro existente",
+		RULE_BEFORE:     "{0} debe ser anterior a {1}",
+		RULE_AFTER:      "{0} debe ser posterior a {1}",
+		RULE_PAST:       "{0} debe estar en el pasado",
+		RULE_FUTURE:     "{0} debe estar en el futuro",
+		RULE_DATE_RANGE: "{0} debe estar dentro de {1}",
+		"required":      "{0} es un campo requerido",
+		"eq":            "{0} no es igual a {1}",
+		"ne":            "{0} no debe ser igual a {1}",
+		"oneof":         "{0} debe ser uno de [{1}]",
+		"alpha":         "{0} sólo puede contener caracteres alfabéticos",
+		"alphanum":      "{0} sólo puede contener caracteres alfanuméricos",
+		"numeric":       "{0} debe ser un valor numérico válido",
+		"number":        "{0} debe ser un número válido",
+		"email":         "{0} debe ser una dirección de correo electrónico válida",
+		"url":           "{0} debe ser una URL válida",
+		"uri":           "{0} debe ser una URI válida",
+		"uuid":          "{0} debe ser un UUID válido",
+		"uuid4":         "{0} debe ser un UUID versión 4 válido",
+		"contains":      "{0} debe contener el texto '{1}'",
+		"excludes":      "{0} no puede contener el texto '{1}'",
+		"eqfield":       "{0} debe ser igual a {1}",
+		"eqcsfield":     "{0} debe ser igual a {1}",
+		"nefield":       "{0} no puede ser igual a {1}",
+		"necsfield":     "{0} no puede ser igual a {1}",
+		"gtfield":       "{0} debe ser mayor que {1}",
+		"gtcsfield":     "{0} debe ser mayor que {1}",
+		"gtefield":      "{0} debe ser mayor o igual a {1}",
+		"gtecsfield":    "{0} debe ser mayor o igual a {1}",
+		"ltfield":       "{0} debe ser menor que {1}",
+		"ltcsfield":     "{0} debe ser menor que {1}",
+		"ltefield":      "{0} debe ser menor o igual a {1}",
+		"ltecsfield":    "{0} debe ser menor o igual a {1}",
+		"hexadecimal":   "{0} debe ser un hexadecimal válido",
+		"hexcolor":      "{0} debe ser un color HEX válido",
+		"rgb":           "{0} debe ser un color RGB válido",
+		"rgba":          "{0} debe ser un color RGBA válido",
+		"hsl":           "{0} debe ser un color HSL válido",
+		"hsla":          "{0} debe ser un color HSLA válido",
+		"iscolor":       "{0} debe ser un color válido",
+		"base64":        "{0} debe ser un texto Base64 válido",
+		"containsany":   "{0} debe contener al menos uno de los caracteres '{1}'",
+		"excludesall":   "{0} no puede contener ninguno de los caracteres '{1}'",
+		"excludesrune":  "{0} no puede contener '{1}'",
+		"isbn":          "{0} debe ser un número ISBN válido",
+		"isbn10":        "{0} debe ser un número ISBN-10 válido",
+		"isbn13":        "{0} debe ser un número ISBN-13 válido",
+		"uuid3":         "{0} debe ser un UUID versión 3 válido",
+		"uuid5":         "{0} debe ser un UUID versión 5 válido",
+		"ascii":         "{0} sólo puede contener caracteres ASCII",
+		"printascii":    "{0} sólo puede contener caracteres ASCII imprimibles",
+		"multibyte":     "{0} debe contener caracteres multibyte",
+		"datauri":       "{0} debe ser una URI de datos válida",
+		"latitude":      "{0} debe contener una latitud válida",
+		"longitude":     "{0} debe contener una longitud válida",
+		"ssn":           "{0} debe ser un número SSN válido",
+		"ip":            "{0} debe ser una dirección IP válida",
+		"ipv4":          "{0} debe ser una dirección IPv4 válida",
+		"ipv6":          "{0} debe ser una dirección IPv6 válida",
+		"cidr":          "{0} debe contener una notación CIDR válida",
+		"cidrv4":        "{0} debe contener una notación CIDR válida para una dirección IPv4",
+		"cidrv6":        "{0} debe contener una notación CIDR válida para una dirección IPv6",
+		"tcp_addr":      "{0} debe ser una dirección TCP válida",
+		"tcp4_addr":     "{0} debe ser una dirección IPv4 TCP válida",
+		"tcp6_addr":     "{0} debe ser una dirección IPv6 TCP válida",
+		"udp_addr":      "{0} debe ser una dirección UDP válida",
+		"udp4_addr":     "{0} debe ser una dirección IPv4 UDP válida",
+		"udp6_addr":     "{0} debe ser una dirección IPv6 UDP válida",
+		"ip_addr":       "{0} debe ser una dirección IP resoluble",
+		"ip4_addr":      "{0} debe ser una dirección IPv4 resoluble",
+		"ip6_addr":      "{0} debe ser una dirección IPv6 resoluble",
+		"unix_addr":     "{0} debe ser una dirección UNIX resoluble",
+		"mac":           "{0} debe contener una dirección MAC válida",
+		"unique":        "{0} debe contener valores únicos",
+	},
+}
+
+// lengthTemplates are the messages of the rules that depend on whether the field is a string, a collection or a number
+var lengthTemplates = map[string]map[string][3]string{
+	"es": {
+		"len": {"{0} debe tener {1} caracteres", "{0} debe contener {1} elementos", "{0} debe ser igual a {1}"},
+		"min": {"{0} debe tener al menos {1} caracteres", "{0} debe contener al menos {1} elementos", "{0} debe ser {1} o más"},
+		"max": {"{0} debe tener como máximo {1} caracteres", "{0} debe contener como máximo {1} elementos", "{0} debe ser {1} o menos"},
+		"gt":  {"{0} debe tener más de {1} caracteres", "{0} debe contener más de {1} elementos", "{0} debe ser mayor que {1}"},
+		"gte": {"{0} debe tener al menos {1} caracteres", "{0} debe contener al menos {1} elementos", "{0} debe ser {1} o más"},
+		"lt":  {"{0} debe tener menos de {1} caracteres", "{0} debe contener menos de {1} elementos", "{0} debe ser menor que {1}"},
+		"lte": {"{0} debe tener como máximo {1} caracteres", "{0} debe contener como máximo {1} elementos", "{0} debe ser {1} o menos"},
+	},
+}
+
+// GetTranslator returns the translator of the first supported language of an Accept-Language header.  The
+// DEFAULT_LOCALE translator is returned when none of them are supported.
+func (pgv *PlaygroundValidator) GetTranslator(acceptLanguage string) ut.Translator {
+	if pgv.uni == nil {
+		return defaultTranslator
+	}
+
+	trans, _ := pgv.uni.FindTranslator(parseAcceptLanguage(acceptLanguage)...)
+
+	return trans
+}
+
+// GetTranslator returns the translator of the Singleton validator for an Accept-Language header
+func GetTranslator(acceptLanguage string) ut.Translator {
+	return GetValidatorTranslator(Singleton(), acceptLanguage)
+}
+
+// GetValidatorTranslator returns the translator of the validator for an Accept-Language header.  A translator without
+// any messages is returned when the validator doesn't implement TranslatorProvider.
+func GetValidatorTranslator(v *Validator, acceptLanguage string) ut.Translator {
+	if v != nil {
+		if tp, ok := (*v).(TranslatorProvider); ok {
+			return tp.GetTranslator(acceptLanguage)
+		}
+	}
+
+	return defaultTranslator
+}
+
+// Translate returns the message of the error in the language of the translator.  The untranslated message is returned
+// when there isn't a template for the rule.
+func Translate(e Error, trans ut.Translator) string {
+	if fe, ok := e.(FieldError); ok && fe.fieldError != nil {
+		if msg := fe.fieldError.Translate(trans); msg != fe.fieldError.(error).Error() {
+			return msg
+		}
+	}
+
+	param := ""
+	if p, ok := e.(interface{ Param() string }); ok {
+		param = p.Param()
+	}
+
+	if msg, err := trans.T(e.Rule(), e.Field(), param); err == nil {
+		return msg
+	}
+
+	return GetMessage(e)
+}
+
+// RegisterTranslation adds or replaces the message of a rule in a locale.  {0} is replaced by the field and {1} by the
+// parameter of the rule.  Only the messages of this validator are changed.
+func (pgv *PlaygroundValidator) RegisterTranslation(locale string, rule string, template string) error {
+	v, err := pgv.validate()
+	if err != nil {
+		return err
+	}
+
+	if pgv.uni == nil {
+		return errors.New("the validator does not support translations")
+	}
+
+	trans, found := pgv.uni.GetTranslator(locale)
+	if !found {
+		return fmt.Errorf("locale '%s' is not supported", locale)
+	}
+
+	if err := trans.Add(rule, template, true); err != nil {
+		return err
+	}
+
+	return v.RegisterTranslation(rule, trans, func(ut.Translator) error { return nil }, translateField)
+}
+
+// RegisterTranslation adds or replaces the message of a rule in a locale for the Singleton validator
+func RegisterTranslation(locale string, rule string, template string) error {
+	pgv, ok := (*Singleton()).(*PlaygroundValidator)
+	if !ok {
+		return fmt.Errorf("the validator does not support translations")
+	}
+
+	return pgv.RegisterTranslation(locale, rule, template)
+}
+
+// registerTranslations adds the messages of every supported locale to the validator.  The messages of the rules of the
+// validator library aren't replaced, a template for one of them panics unless the locale doesn't have it yet.
+func registerTranslations(v *validator.Validate, uni *ut.UniversalTranslator) {
+	enTrans, _ := uni.GetTranslator("en")
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		panic(err)
+	}
+
+	for locale, rules := range templates {
+		trans, _ := uni.GetTranslator(locale)
+		for rule, template := range rules {
+			if err := trans.Add(rule, template, false); err != nil {
+				panic(err)
+			}
+
+			if err := v.RegisterTranslation(rule, trans, func(ut.Translator) error { return nil }, translateField); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	for locale, rules := range lengthTemplates {
+		trans, _ := uni.GetTranslator(locale)
+		for rule, t := range rules {
+			for i, template := range t {
+				if err := trans.Add(lengthKey(rule, i), template, false); err != nil {
+					panic(err)
+				}
+			}
+
+			if err := v.RegisterTranslation(rule, trans, func(ut.Translator) error { return nil }, translateLength); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// translateField implements validator.TranslationFunc for the templates
+func translateField(trans ut.Translator, fe validator.FieldError) string {
+	msg, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.(error).Error()
+	}
+
+	return msg
+}
+
+// translateLength implements validator.TranslationFunc for the lengthTemplates
+func translateLength(trans ut.Translator, fe validator.FieldError) string {
+	i := 2
+	switch fe.Kind() {
+	case reflect.String:
+		i = 0
+	case reflect.Slice, reflect.Map, reflect.Array:
+		i = 1
+	}
+
+	msg, err := trans.T(lengthKey(fe.Tag(), i), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.(error).Error()
+	}
+
+	return msg
+}
+
+func lengthKey(rule string, i int) string {
+	return rule + "-" + strconv.Itoa(i)
+}
+
+// parseAcceptLanguage returns the acceptable locales of the header ordered by their quality.  A region specific locale
+// is followed by its base language, e.g. "es-MX" becomes "es_mx" and "es".
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	languages := make([]language, 0)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(strings.TrimSpace(part), ";")
+		if params[0] == "" || params[0] == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, p := range params[1:] {
+			if q := strings.TrimPrefix(strings.TrimSpace(p), "q="); q != strings.TrimSpace(p) {
+				if f, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = f
+				}
+			}
+		}
+
+		// a quality of 0 means the language is not acceptable
+		if quality <= 0 {
+			continue
+		}
+
+		languages = append(languages, language{tag: params[0], quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+
+	locales := make([]string, 0, len(languages)*2)
+	for _, l := range languages {
+		locale := strings.ToLower(strings.Replace(l.tag, "-", "_", -1))
+		locales = append(locales, locale)
+		if i := strings.Index(locale, "_"); i > 0 {
+			locales = append(locales, locale[:i])
+		}
+	}
+
+	return locales
+}
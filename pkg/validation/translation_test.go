@@ -0,0 +1,188 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []string
+	}{
+		{"", []string{}},
+		{"es", []string{"es"}},
+		{"en;q=0.5, es-MX", []string{"es_mx", "es", "en"}},
+		{"fr, *;q=0.1", []string{"fr"}},
+		{"es;q=0, en", []string{"en"}},
+		{"es; q=0.0", []string{}},
+	}
+
+	for _, tt := range tests {
+		if locales := parseAcceptLanguage(tt.header); !reflect.DeepEqual(locales, tt.expected) {
+			t.Errorf("Expected %v for '%s', got %v", tt.expected, tt.header, locales)
+		}
+	}
+}
+
+func TestGetTranslator(t *testing.T) {
+	if locale := GetTranslator("es-ES").Locale(); locale != "es" {
+		t.Errorf("Expected es, got %s", locale)
+	}
+
+	if locale := GetTranslator("fr").Locale(); locale != DEFAULT_LOCALE {
+		t.Errorf("Expected the default locale for an unsupported language, got %s", locale)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	type model struct {
+		Name  string `json:"name" validate:"notblank=- -"`
+		Email string `json:"email" validate:"omitempty,email"`
+	}
+
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+	err := pgv.Struct(model{Name: "", Email: "invalid"})
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected 2 field errors, got %v", err)
+	}
+
+	tests := []struct {
+		language string
+		expected []string
+	}{
+		{"en", []string{"name cannot be blank", "email must be a valid email address"}},
+		{"es", []string{"name no puede estar vacío", "email debe ser una dirección de correo electrónico válida"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			trans := pgv.GetTranslator(tt.language)
+			for i, e := range errs {
+				if msg := Translate(e, trans); msg != tt.expected[i] {
+					t.Errorf("Expected %s, got %s", tt.expected[i], msg)
+				}
+			}
+		})
+	}
+
+	t.Run("Translate the errors of DecodeRequest", func(t *testing.T) {
+		e := FieldError{field: "invalid", rule: RULE_UNKNOWN, message: "This property does not exist."}
+		if msg := Translate(e, GetTranslator("es")); msg != "invalid no existe" {
+			t.Errorf("Expected a Spanish message, got %s", msg)
+		}
+	})
+
+	t.Run("Fall back to the message for a rule without a template", func(t *testing.T) {
+		e := newFieldError("name", "custom", "a")
+		if msg := Translate(e, GetTranslator("es")); msg != e.Message() {
+			t.Errorf("Expected %s, got %s", e.Message(), msg)
+		}
+	})
+}
+
+func TestTranslate_EveryRuleInSpanish(t *testing.T) {
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+	v, err := pgv.validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the validator library doesn't list the rules that have a message so they are read from the validator
+	rules := func(locale string) map[string]bool {
+		trans, _ := pgv.uni.GetTranslator(locale)
+		funcs := reflect.ValueOf(v).Elem().FieldByName("transTagFunc").MapIndex(reflect.ValueOf(trans))
+		registered := make(map[string]bool)
+		for _, rule := range funcs.MapKeys() {
+			registered[rule.String()] = true
+		}
+
+		return registered
+	}
+
+	en, es := rules("en"), rules("es")
+	if len(en) < 75 {
+		t.Fatalf("Expected the messages of the validator library, got %d", len(en))
+	}
+
+	for rule := range en {
+		if !es[rule] {
+			t.Errorf("Expected a Spanish message for %s", rule)
+		}
+	}
+
+	model := struct {
+		Min   int    `json:"min"`
+		Max   int    `json:"max" validate:"gtfield=Min"`
+		Color string `json:"color" validate:"hexcolor"`
+	}{2, 1, "red"}
+
+	var errs Errors
+	if err := pgv.Struct(model); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected 2 field errors, got %v", err)
+	}
+
+	expected := []string{"max debe ser mayor que Min", "color debe ser un color HEX válido"}
+	for i, e := range errs {
+		if msg := Translate(e, pgv.GetTranslator("es")); msg != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], msg)
+		}
+	}
+}
+
+func TestPlaygroundValidator_RegisterTranslation(t *testing.T) {
+	pgv := NewPlaygroundValidator().(*PlaygroundValidator)
+
+	if err := pgv.RegisterTranslation("fr", "required", "{0} est requis"); err == nil {
+		t.Error("Expected error for an unsupported locale and got none")
+	}
+
+	if err := pgv.RegisterTranslation("es", "email", "{0 no es un correo"); err == nil {
+		t.Error("Expected error for an invalid template and got none")
+	}
+
+	if err := pgv.RegisterTranslation("es", "email", "{0} no es un correo"); err != nil {
+		t.Fatalf("Did not expect error and got: %s", err)
+	}
+
+	model := struct {
+		Email string `json:"email" validate:"email"`
+	}{"invalid"}
+
+	var errs Errors
+	if err := pgv.Struct(model); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Expected 1 field error, got %v", err)
+	}
+
+	if msg := Translate(errs[0], pgv.GetTranslator("es")); msg != "email no es un correo" {
+		t.Errorf("Expected the registered message, got %s", msg)
+	}
+
+	t.Run("Keep the messages of the other validators", func(t *testing.T) {
+		other := NewPlaygroundValidator().(*PlaygroundValidator)
+
+		var errs Errors
+		if err := other.Struct(model); !errors.As(err, &errs) || len(errs) != 1 {
+			t.Fatalf("Expected 1 field error, got %v", err)
+		}
+
+		if msg := Translate(errs[0], other.GetTranslator("es")); msg != "email debe ser una dirección de correo electrónico válida" {
+			t.Errorf("Expected the default message, got %s", msg)
+		}
+	})
+}
+
+func TestGetValidatorTranslator(t *testing.T) {
+	var v Validator = &PlaygroundValidator{validator: MockPlaygroundValidatorAdaptor{}}
+	if trans := GetValidatorTranslator(&v, "es"); trans != defaultTranslator {
+		t.Errorf("Expected the default translator for a validator without translations, got %s", trans.Locale())
+	}
+
+	pgv := NewPlaygroundValidator()
+	if locale := GetValidatorTranslator(&pgv, "es-MX").Locale(); locale != "es" {
+		t.Errorf("Expected es, got %s", locale)
+	}
+}
@@ -1,5 +1,10 @@
 package validation
 
+import (
+	"context"
+	"reflect"
+)
+
 var v *Validator
 
 type Validator interface {
@@ -7,6 +12,55 @@ type Validator interface {
 	Struct(s interface{}) error
 }
 
+// ContextValidator is implemented by validators whose rules can use the context of the request, e.g. the database
+// rules of PlaygroundValidator which are cancelled along with the request
+type ContextValidator interface {
+	VarContext(ctx context.Context, field interface{}, options interface{}) error
+	StructContext(ctx context.Context, s interface{}) error
+}
+
+// VarContext calls v.VarContext when the validator is a ContextValidator and v.Var otherwise
+func VarContext(ctx context.Context, v Validator, field interface{}, options interface{}) error {
+	if cv, ok := v.(ContextValidator); ok {
+		return cv.VarContext(ctx, field, options)
+	}
+
+	return v.Var(field, options)
+}
+
+// StructContext calls v.StructContext when the validator is a ContextValidator and v.Struct otherwise
+func StructContext(ctx context.Context, v Validator, s interface{}) error {
+	if cv, ok := v.(ContextValidator); ok {
+		return cv.StructContext(ctx, s)
+	}
+
+	return v.Struct(s)
+}
+
+// RuleRegistrar is implemented by validators that custom rules can be added to, e.g. PlaygroundValidator:
+//
+//	(*validation.Singleton()).(validation.RuleRegistrar).RegisterRule("statecode", fn)
+type RuleRegistrar interface {
+	// RegisterRule adds a rule that can be used in the `validate` tag by its name, e.g. `validate:"statecode"`
+	RegisterRule(name string, fn RuleFunc) error
+	// RegisterStructRule adds a rule that validates the whole struct for each of the models, e.g. fields that depend on
+	// each other
+	RegisterStructRule(fn StructRuleFunc, models ...interface{}) error
+	// RegisterType adds a function that extracts the value to validate from each of the values' types, e.g.
+	// ValidateValuer
+	RegisterType(fn TypeFunc, values ...interface{}) error
+}
+
+// RuleFunc returns true when the field is valid.  The param is the text after the `=` of the rule, e.g. "3" for min=3.
+type RuleFunc func(field reflect.Value, param string) bool
+
+// StructRuleFunc validates the struct s and calls report with the json name of the field and the name of the rule for
+// every field that is invalid
+type StructRuleFunc func(s interface{}, report func(field string, rule string))
+
+// TypeFunc returns the value that is validated in place of the field
+type TypeFunc func(field reflect.Value) interface{}
+
 // Singleton will return the global validator variable and create it if necessary
 func Singleton() *Validator {
 	if v == nil {
@@ -1,6 +1,9 @@
 package validation
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestSingleton(t *testing.T) {
 	v := Singleton()
@@ -15,3 +18,34 @@ func TestSingleton(t *testing.T) {
 		t.Error("Singleton did not return the same address of the validator")
 	}
 }
+
+// structValidator only implements Validator and records the calls
+type structValidator struct {
+	called bool
+}
+
+func (v *structValidator) Var(field interface{}, options interface{}) error {
+	v.called = true
+	return nil
+}
+
+func (v *structValidator) Struct(s interface{}) error {
+	v.called = true
+	return nil
+}
+
+func TestStructContext(t *testing.T) {
+	sv := &structValidator{}
+	if err := StructContext(context.Background(), sv, struct{}{}); err != nil || !sv.called {
+		t.Errorf("Expected Struct to be called for a validator without a context, got %v", err)
+	}
+
+	sv = &structValidator{}
+	if err := VarContext(context.Background(), sv, "a", "required"); err != nil || !sv.called {
+		t.Errorf("Expected Var to be called for a validator without a context, got %v", err)
+	}
+
+	if _, ok := NewPlaygroundValidator().(ContextValidator); !ok {
+		t.Error("Expected the PlaygroundValidator to be a ContextValidator")
+	}
+}